@@ -1,8 +1,14 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/GGGLHHH/go-generate-type/pkg/typegen"
 )
@@ -11,6 +17,24 @@ func main() {
 	var opts typegen.Options
 	var outputPath string
 	var toStdout bool
+	var format string
+	var watch bool
+	var watchOpts typegen.WatchOptions
+	var configPath string
+	var collisions string
+	var fieldCasing string
+	var nullability string
+	var mutations string
+	var int64Mode string
+	var brands string
+	var targets []typegen.Target
+
+	// "typegen check [flags]" compares the output file with a fresh generation.
+	args := os.Args[1:]
+	check := len(args) > 0 && args[0] == "check"
+	if check {
+		args = args[1:]
+	}
 
 	flag.StringVar(&opts.PkgPath, "pkg-path", "", "Go module import path for pkg root (default: <module>/pkg)")
 	flag.StringVar(&opts.PkgDir, "pkg-dir", "", "Filesystem path to pkg directory (required)")
@@ -18,17 +42,167 @@ func main() {
 	flag.StringVar(&opts.IncludePattern, "include-file", "", "Regexp for source file paths to include in output")
 	flag.StringVar(&opts.IncludeType, "include-type", "", "Regexp for exported type names to include in output")
 	flag.BoolVar(&opts.StripPrefix, "strip-prefix", false, "Remove package prefixes from generated identifiers")
+	flag.StringVar(&collisions, "strip-prefix-collisions", "", "How -strip-prefix resolves clashing names: error (default), package-suffix or pascal-prefix")
+	flag.StringVar(&opts.FieldTag, "field-tag", "", "Struct tag property names are read from, e.g. yaml, msgpack or form (default: json)")
+	flag.StringVar(&fieldCasing, "field-casing", "", "Casing for fields without a name in that tag: camel, snake or kebab (default: Go name)")
 	flag.BoolVar(&opts.DisableRename, "disable-rename", false, "Skip rename scan (TypeNameMapper ignored)")
-	defaultOut := typegen.DefaultOutputPath()
-	flag.StringVar(&outputPath, "out", defaultOut, "Output file path (defaults to index.d.ts next to the executable)")
-	flag.StringVar(&outputPath, "out-file", defaultOut, "Output file path (alias of -out)")
+	flag.StringVar(&brands, "brands", "", "Comma-separated packages relative to -pkg-dir whose defined scalar types become branded types, e.g. user,order/...")
+	flag.BoolVar(&opts.BrandHelpers, "brand-helpers", false, "Emit a constructor function per branded type (requires a .ts output, e.g. -out index.ts)")
+	flag.StringVar(&int64Mode, "int64", "", "TypeScript type of int64 and uint64: number (default), string, bigint or branded")
+	flag.StringVar(&mutations, "mutations", "", "Comma-separated guts mutations to apply, or -name entries to remove from the defaults")
+	flag.StringVar(&nullability, "nullability", "", "Typing of fields that may be empty, e.g. pointer=optional,slice=null (policies: null, optional, undefined, required)")
+	flag.BoolVar(&opts.JSONv2, "json-v2", false, "Generate what encoding/json/v2 marshals (inline, unknown and format options)")
+	flag.StringVar(&opts.ClientImport, "client-import", "", "Module the -format client output imports the generated types from (default ./index)")
+	flag.BoolVar(&opts.Strict, "strict", false, "Fail when a package fails to load instead of skipping it")
+	flag.StringVar(&outputPath, "out", "", "Output file path (defaults to index.d.ts, schemas.ts, schema.json, openapi.yaml/json, client.ts, routes.ts or constants.ts by -format, next to the executable)")
+	flag.StringVar(&outputPath, "out-file", "", "Output file path (alias of -out)")
 	flag.BoolVar(&toStdout, "stdout", false, "Write output to stdout instead of a file")
-	flag.Parse()
+	flag.StringVar(&format, "format", string(typegen.FormatTypeScript), "Output format: ts, zod, jsonschema, openapi, openapi-json, client, routes or constants")
+	flag.BoolVar(&watch, "watch", false, "Regenerate whenever a .go file in the scanned packages changes")
+	flag.DurationVar(&watchOpts.Interval, "watch-interval", 0, "Polling interval for -watch (default 500ms)")
+	flag.DurationVar(&watchOpts.Debounce, "watch-debounce", 0, "How long sources must stay unchanged before -watch regenerates (default 300ms)")
+	flag.StringVar(&configPath, "config", "", "Config file (default: typegen.yaml, typegen.yml or typegen.json next to go.mod)")
+	if err := flag.CommandLine.Parse(args); err != nil {
+		os.Exit(2)
+	}
+
+	if configPath == "" {
+		found, err := typegen.FindConfig(".")
+		if err != nil {
+			log.Fatalf("find config: %v", err)
+		}
+		configPath = found
+	}
+	if configPath != "" {
+		cfg, err := typegen.LoadConfig(configPath)
+		if err != nil {
+			log.Fatalf("load config: %v", err)
+		}
+		opts = cfg.Options()
+		targets = cfg.OutputTargets()
+		collisions = string(opts.Collisions)
+		fieldCasing = string(opts.FieldCasing)
+		int64Mode = string(opts.Int64)
+		fileOutput := cfg.OutputOptions()
+		outputPath = fileOutput.OutputPath
+		toStdout = fileOutput.Stdout
+		if fileOutput.Format != "" {
+			format = string(fileOutput.Format)
+		}
+		// Parse again so flags given on the command line override the file.
+		if err := flag.CommandLine.Parse(args); err != nil {
+			os.Exit(2)
+		}
+		if len(targets) > 0 {
+			if conflicts := targetFlags(); len(conflicts) > 0 {
+				log.Fatalf("%s conflict with the targets of %s; set them per target instead", strings.Join(conflicts, ", "), configPath)
+			}
+		}
+	}
+
+	opts.Collisions = typegen.CollisionStrategy(collisions)
+	opts.FieldCasing = typegen.FieldCasing(fieldCasing)
+	opts.Int64 = typegen.Int64Mode(int64Mode)
+	if brands != "" {
+		opts.Brands = splitList(brands)
+	}
+	if mutations != "" {
+		opts.Mutations = splitList(mutations)
+	}
+	if nullability != "" {
+		n, err := typegen.ParseNullability(nullability)
+		if err != nil {
+			log.Fatalf("-nullability: %v", err)
+		}
+		// Categories given on the command line override the config file.
+		opts.Nullability.Pointer = cmp.Or(n.Pointer, opts.Nullability.Pointer)
+		opts.Nullability.Omitempty = cmp.Or(n.Omitempty, opts.Nullability.Omitempty)
+		opts.Nullability.Slice = cmp.Or(n.Slice, opts.Nullability.Slice)
+		opts.Nullability.Map = cmp.Or(n.Map, opts.Nullability.Map)
+	}
+	opts.OnDiagnostic = func(d typegen.Diagnostic) {
+		switch d.Kind {
+		case typegen.DiagnosticConflict:
+			log.Printf("warning: declaration dropped: %s", d)
+		default:
+			log.Printf("warning: package %s", d)
+		}
+	}
 
-	if err := typegen.GenerateTypesToOutput(opts, typegen.OutputOptions{
+	output := typegen.OutputOptions{
 		OutputPath: outputPath,
 		Stdout:     toStdout,
-	}); err != nil {
+		Format:     typegen.Format(format),
+	}
+
+	// Config targets replace the single output described by the flags.
+	if len(targets) == 0 {
+		targets = []typegen.Target{{
+			IncludePattern: opts.IncludePattern,
+			IncludeType:    opts.IncludeType,
+			StripPrefix:    opts.StripPrefix,
+			DisableRename:  opts.DisableRename,
+			FieldTag:       opts.FieldTag,
+			FieldCasing:    opts.FieldCasing,
+			Output:         output,
+		}}
+	}
+
+	if check {
+		err := typegen.CheckTargets(opts, targets)
+		if err != nil {
+			printDiffs(err)
+			log.Fatalf("check: %v", err)
+		}
+		return
+	}
+
+	if watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := typegen.WatchTargets(ctx, opts, targets, watchOpts); err != nil {
+			log.Fatalf("watch: %v", err)
+		}
+		return
+	}
+
+	if err := typegen.GenerateTargets(opts, targets); err != nil {
 		log.Fatalf("generate types: %v", err)
 	}
 }
+
+// targetFlags returns the flags given on the command line that describe a
+// single output, which config targets each set themselves.
+func targetFlags() []string {
+	var set []string
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "out", "out-file", "stdout", "format", "include", "include-file", "include-type",
+			"strip-prefix", "disable-rename", "field-tag", "field-casing":
+			set = append(set, "-"+f.Name)
+		}
+	})
+	return set
+}
+
+// splitList splits a comma-separated flag value, so "a, b" works like "a,b".
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+// printDiffs prints the diff of every *typegen.DriftError in err.
+func printDiffs(err error) {
+	if drift, ok := err.(*typegen.DriftError); ok {
+		fmt.Print(drift.Diff)
+		return
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			printDiffs(err)
+		}
+	}
+}
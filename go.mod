@@ -2,7 +2,10 @@ module github.com/GGGLHHH/go-generate-type
 
 go 1.25.0
 
-require github.com/coder/guts v1.6.1
+require (
+	github.com/coder/guts v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/dlclark/regexp2 v1.11.4 // indirect
@@ -10,6 +13,7 @@ require (
 	github.com/fatih/structtag v1.2.0 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
+	github.com/kr/text v0.2.0 // indirect
 	golang.org/x/mod v0.27.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
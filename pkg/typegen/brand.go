@@ -0,0 +1,150 @@
+package typegen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+// Directives controlling branded types in the doc comment of a defined
+// type. They take precedence over Options.Brands.
+const (
+	directiveBrand   = "typegen:brand"
+	directiveNoBrand = "typegen:nobrand"
+)
+
+// brandBases are the Go scalar types a defined type may brand.
+var brandBases = map[string]bool{
+	"string": true, "bool": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+func validateBrandPackages(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" || strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("invalid brand package %q: want a path relative to pkg-dir", pattern)
+		}
+	}
+	return nil
+}
+
+// matchPackage reports whether the package at rel, relative to PkgDir,
+// matches one of patterns: "." is PkgDir itself, "order" a single package
+// and "order/..." the package and those below it ("..." matches all).
+func matchPackage(patterns []string, rel string) bool {
+	if rel == "" {
+		rel = "."
+	}
+	for _, pattern := range patterns {
+		if pattern == "..." || pattern == rel {
+			return true
+		}
+		if base, ok := strings.CutSuffix(pattern, "/..."); ok && (rel == base || strings.HasPrefix(rel, base+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBrands turns defined scalar types of the packages matching
+// patterns, or carrying the brand directive, into branded types. The brand
+// is the qualified Go name, "<import path>.<Name>", so types of the same
+// name in different packages stay distinct. Enums are never branded.
+func applyBrands(decls []*declaration, scan *sourceScan, patterns []string) {
+	types := make(map[string]scannedType, len(scan.types))
+	for _, st := range scan.types {
+		types[st.key] = st
+	}
+
+	for _, decl := range decls {
+		alias, ok := decl.node.(*bindings.Alias)
+		if !ok {
+			continue
+		}
+		st, ok := types[decl.key]
+		if !ok || st.kind != KindAlias {
+			continue
+		}
+		ident, ok := st.goType.(*ast.Ident)
+		if !ok || !brandBases[ident.Name] {
+			continue
+		}
+		switch {
+		case st.hasDirective(directiveNoBrand):
+			continue
+		case st.hasDirective(directiveBrand), matchPackage(patterns, st.rel):
+		default:
+			continue
+		}
+
+		// Keep bigint and string from Options.Int64, but not its brand:
+		// two brands intersect to never.
+		baseType := alias.Type
+		if ref, ok := baseType.(*bindings.ReferenceType); ok && ref.Name.Name == int64BrandName {
+			baseType = keyword(bindings.KeywordString)
+		}
+		if _, ok := baseType.(*bindings.LiteralKeyword); !ok {
+			continue
+		}
+		alias.Type = brandedType(st.importPath+"."+st.name, baseType)
+		decl.refs = referencedKeys(decl.node)
+	}
+}
+
+// checkBrandHelpers rejects Options.BrandHelpers for a .d.ts output, which
+// cannot hold the function bodies of the helpers.
+func checkBrandHelpers(opts Options, output OutputOptions) error {
+	if !opts.BrandHelpers || output.Stdout || output.OutputPath == "-" {
+		return nil
+	}
+	if output.Format != "" && output.Format != FormatTypeScript {
+		return nil
+	}
+	if outPath := resolveOutputPath(output); strings.HasSuffix(outPath, ".d.ts") {
+		return fmt.Errorf("brand helpers need a .ts output, not %s", outPath)
+	}
+	return nil
+}
+
+// brandedTypeHelper returns a constructor function for decl when it
+// declares a branded type, e.g.
+//
+//	export function UserID(value: string): UserID {
+//	    return value as UserID;
+//	}
+func brandedTypeHelper(decl *declaration) string {
+	alias, ok := decl.node.(*bindings.Alias)
+	if !ok || len(alias.Parameters) > 0 {
+		return ""
+	}
+	intersection, ok := alias.Type.(*bindings.TypeIntersection)
+	if !ok {
+		return ""
+	}
+	base, _, ok := brandOf(intersection)
+	if !ok {
+		return ""
+	}
+	kw, ok := base.(*bindings.LiteralKeyword)
+	if !ok {
+		return ""
+	}
+	var param string
+	switch *kw {
+	case bindings.KeywordString:
+		param = "string"
+	case bindings.KeywordNumber:
+		param = "number"
+	case bindings.KeywordBoolean:
+		param = "boolean"
+	case bindings.KeywordBigInt:
+		param = "bigint"
+	default:
+		return ""
+	}
+	return fmt.Sprintf("export function %[1]s(value: %[2]s): %[1]s {\n    return value as %[1]s;\n}\n", decl.name, param)
+}
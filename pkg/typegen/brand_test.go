@@ -0,0 +1,128 @@
+package typegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_Brands(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type UserID string
+
+// Name is free text.
+//
+//typegen:nobrand
+type Name string
+
+type User struct {
+	ID   UserID
+	Name Name
+}
+`)
+	writeFile(t, root, "pkg/org/org.go", `package org
+
+type OrgID string
+
+// Seq orders events.
+//
+//typegen:brand
+type Seq int64
+
+type Status string
+
+const StatusActive Status = "active"
+
+type Org struct {
+	ID     OrgID
+	Seq    Seq
+	Status Status
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true, Brands: []string{"user/..."}}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"export type UserID = string & {\n    readonly __brand: \"example.com/test/pkg/user.UserID\";\n};",
+		"export type Seq = number & {",
+		"export type Name = string;",
+		"export type OrgID = string;",
+		`export type Status = "active";`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "export function") {
+		t.Fatalf("expected no helpers without BrandHelpers:\n%s", output)
+	}
+
+	opts.BrandHelpers = true
+	opts.Int64 = Int64Branded
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (helpers): %v", err)
+	}
+	for _, want := range []string{
+		"export function UserID(value: string): UserID {\n    return value as UserID;\n}",
+		"export type Seq = string & {",
+		"export function Seq(value: string): Seq {",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Int64String") {
+		t.Fatalf("expected the Seq brand to replace Int64String:\n%s", output)
+	}
+
+	err = GenerateTypesToOutput(opts, OutputOptions{OutputPath: filepath.Join(root, "index.d.ts")})
+	if err == nil || !strings.Contains(err.Error(), "brand helpers need a .ts output") {
+		t.Fatalf("expected helpers to be rejected for a .d.ts output, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(root, "index.d.ts")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected index.d.ts not to be written, got %v", statErr)
+	}
+	if err := GenerateTypesToOutput(opts, OutputOptions{OutputPath: filepath.Join(root, "index.ts")}); err != nil {
+		t.Fatalf("GenerateTypesToOutput (.ts): %v", err)
+	}
+}
+
+func TestGenerateTypes_BrandsArePackageQualified(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/a/a.go", `package a
+
+type ID string
+`)
+	writeFile(t, root, "pkg/b/b.go", `package b
+
+type ID string
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateTypesWithOptions(Options{PkgDir: filepath.Join(root, "pkg"), Brands: []string{"..."}})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		`readonly __brand: "example.com/test/pkg/a.ID";`,
+		`readonly __brand: "example.com/test/pkg/b.ID";`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package typegen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DriftError reports that a generated file on disk differs from what the
+// generator produces now. Diff is a unified diff limited to the differing
+// declarations, from the file on disk to the fresh output.
+type DriftError struct {
+	Path string
+	Diff string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("%s is out of date, regenerate it", e.Path)
+}
+
+// Check generates TypeScript types in memory and compares them with the file
+// at outPath without writing anything. It returns a *DriftError when they
+// differ.
+func Check(opts Options, outPath string) error {
+	return CheckOutput(opts, OutputOptions{OutputPath: outPath})
+}
+
+// CheckOutput is Check for any output format. OutputPath defaults the same
+// way as in GenerateTypesToOutput; Stdout is ignored.
+func CheckOutput(opts Options, output OutputOptions) error {
+	if err := checkBrandHelpers(opts, output); err != nil {
+		return err
+	}
+	content, err := generateFormat(opts, output.Format)
+	if err != nil {
+		return err
+	}
+	return checkContent(content, output)
+}
+
+// checkContent compares freshly generated content with the output file.
+func checkContent(content string, output OutputOptions) error {
+	outPath := resolveOutputPath(output)
+	existing, err := os.ReadFile(outPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read %s: %w", outPath, err)
+	}
+
+	if string(existing) == content {
+		return nil
+	}
+
+	name := filepath.Base(outPath)
+	return &DriftError{
+		Path: outPath,
+		Diff: diffDeclarations(name, string(existing), content),
+	}
+}
+
+// textBlock is a blank-line separated chunk of a generated file. Every
+// serialized declaration is one block.
+type textBlock struct {
+	// kind is "function" for brand helpers, which share the name of their
+	// type, and "declaration" for anything else.
+	kind  string
+	name  string
+	start int // 1-based line number of the first line
+	lines []string
+}
+
+// blockKey identifies a block across two versions of a file.
+type blockKey struct {
+	kind, name string
+}
+
+func (block textBlock) key() blockKey {
+	return blockKey{kind: block.kind, name: block.name}
+}
+
+// label names the block in a diff or change summary.
+func (key blockKey) label() string {
+	if key.kind == "function" {
+		return key.name + "()"
+	}
+	return key.name
+}
+
+func splitBlocks(content string) []textBlock {
+	var blocks []textBlock
+	var current *textBlock
+	for i, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		if line == "" {
+			if current != nil {
+				blocks = append(blocks, *current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			current = &textBlock{start: i + 1}
+		}
+		current.lines = append(current.lines, line)
+		if current.name == "" {
+			current.kind, current.name = exportedName(line)
+		}
+	}
+	if current != nil {
+		blocks = append(blocks, *current)
+	}
+	for i := range blocks {
+		if blocks[i].name == "" {
+			blocks[i].name = fmt.Sprintf("#%d", i)
+		}
+	}
+	return blocks
+}
+
+// exportedName returns the kind of block and the identifier declared by an
+// "export ..." line.
+func exportedName(line string) (kind, name string) {
+	for _, prefix := range []string{"export interface ", "export type ", "export const ", "export enum ", "export function "} {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		kind = "declaration"
+		if prefix == "export function " {
+			kind = "function"
+		}
+		rest := strings.TrimPrefix(line, prefix)
+		if i := strings.IndexAny(rest, " {=<(:"); i >= 0 {
+			return kind, rest[:i]
+		}
+		return kind, rest
+	}
+	return "", ""
+}
+
+// diffDeclarations renders a unified diff between two generated files, with
+// one hunk per added, removed or changed declaration.
+func diffDeclarations(name, before, after string) string {
+	oldBlocks := splitBlocks(before)
+	newBlocks := splitBlocks(after)
+
+	oldByKey := make(map[blockKey]textBlock, len(oldBlocks))
+	for _, block := range oldBlocks {
+		oldByKey[block.key()] = block
+	}
+	newByKey := make(map[blockKey]textBlock, len(newBlocks))
+	for _, block := range newBlocks {
+		newByKey[block.key()] = block
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s (on disk)\n+++ %s (generated)\n", name, name)
+
+	for _, block := range oldBlocks {
+		if _, ok := newByKey[block.key()]; ok {
+			continue
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,0 @@ %s\n", block.start, len(block.lines), insertionLine(newBlocks, block.name), block.key().label())
+		for _, line := range block.lines {
+			b.WriteString("-" + line + "\n")
+		}
+	}
+
+	for _, block := range newBlocks {
+		old, ok := oldByKey[block.key()]
+		if !ok {
+			fmt.Fprintf(&b, "@@ -%d,0 +%d,%d @@ %s\n", insertionLine(oldBlocks, block.name), block.start, len(block.lines), block.key().label())
+			for _, line := range block.lines {
+				b.WriteString("+" + line + "\n")
+			}
+			continue
+		}
+		if slices.Equal(old.lines, block.lines) {
+			continue
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@ %s\n", old.start, len(old.lines), block.start, len(block.lines), block.key().label())
+		for _, line := range diffLines(old.lines, block.lines) {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// insertionLine returns the line before which a block called name would sit
+// in blocks, which are ordered by name after the header.
+func insertionLine(blocks []textBlock, name string) int {
+	for _, block := range blocks {
+		if !strings.HasPrefix(block.name, "#") && block.name > name {
+			return block.start - 1
+		}
+	}
+	if len(blocks) == 0 {
+		return 0
+	}
+	last := blocks[len(blocks)-1]
+	return last.start + len(last.lines) - 1
+}
+
+// diffLines returns a line diff of a and b prefixed with " ", "-" or "+".
+// It uses Myers' algorithm in linear space, splitting the inputs at the
+// middle snake, so a changed file of one large block (JSON Schema, OpenAPI)
+// does not need a table of len(a) * len(b) entries.
+func diffLines(a, b []string) []string {
+	var out []string
+	diffRange(a, b, &out)
+	return out
+}
+
+func diffRange(a, b []string, out *[]string) {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*out = append(*out, " "+a[0])
+		a, b = a[1:], b[1:]
+	}
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	common := a[len(a)-n:]
+	a, b = a[:len(a)-n], b[:len(b)-n]
+
+	x, y, ok := middleSnake(a, b)
+	if ok && (x > 0 || y > 0) && (x < len(a) || y < len(b)) {
+		diffRange(a[:x], b[:y], out)
+		diffRange(a[x:], b[y:], out)
+	} else {
+		for _, line := range a {
+			*out = append(*out, "-"+line)
+		}
+		for _, line := range b {
+			*out = append(*out, "+"+line)
+		}
+	}
+	for _, line := range common {
+		*out = append(*out, " "+line)
+	}
+}
+
+// middleSnake runs Myers' search from both ends of a and b at once and
+// returns where the paths meet, which lies on an optimal edit script. It
+// reports false when a or b is empty, or they share no line.
+func middleSnake(a, b []string) (int, int, bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, false
+	}
+	maxD := (len(a) + len(b) + 1) / 2
+	offset := maxD
+	// forward[offset+k] is the furthest x reached on diagonal k = x - y
+	// from the start, backward the same from the end.
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := len(a) - len(b)
+	odd := delta%2 != 0
+	// Diagonals leaving the edit graph are trimmed from the search.
+	var fStart, fEnd, bStart, bEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < len(a) && y < len(b) && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > len(a):
+				fEnd += 2
+			case y > len(b):
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && x >= len(a)-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < len(a) && y < len(b) && a[len(a)-x-1] == b[len(b)-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > len(a):
+				bEnd += 2
+			case y > len(b):
+				bStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= len(a)-x {
+						return fx, fx - (delta - k), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,123 @@
+package typegen
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck_ReportsDriftWithoutWriting(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type FooReq struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Bar struct {
+	ID int `+"`json:\"id\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg")}
+	outPath := filepath.Join(root, "index.d.ts")
+	if err := GenerateTypesToOutput(opts, OutputOptions{OutputPath: outPath}); err != nil {
+		t.Fatalf("GenerateTypesToOutput: %v", err)
+	}
+	if err := Check(opts, outPath); err != nil {
+		t.Fatalf("expected freshly generated file to pass check: %v", err)
+	}
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type FooReq struct {
+	Name int `+"`json:\"name\"`"+`
+}
+`)
+	before, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	err = Check(opts, outPath)
+	var drift *DriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("expected DriftError, got %v", err)
+	}
+	for _, want := range []string{
+		"-    readonly name: string;\n+    readonly name: number;\n",
+		"-export interface Bar {\n",
+		"@@ -3,4 +2,0 @@ Bar\n",
+	} {
+		if !strings.Contains(drift.Diff, want) {
+			t.Fatalf("expected %q in diff:\n%s", want, drift.Diff)
+		}
+	}
+	if strings.Contains(drift.Diff, "Code generated") {
+		t.Fatalf("did not expect unchanged header in diff:\n%s", drift.Diff)
+	}
+
+	after, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("check must not modify the output file")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, rng.IntN(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.IntN(4)))
+		}
+		return lines
+	}
+	for range 2000 {
+		a, b := random(), random()
+		var before, after []string
+		kept := 0
+		for _, line := range diffLines(a, b) {
+			switch line[0] {
+			case ' ':
+				before = append(before, line[1:])
+				after = append(after, line[1:])
+				kept++
+			case '-':
+				before = append(before, line[1:])
+			case '+':
+				after = append(after, line[1:])
+			}
+		}
+		if !slices.Equal(before, a) || !slices.Equal(after, b) {
+			t.Fatalf("diff of %q and %q does not rebuild them: %q, %q", a, b, before, after)
+		}
+		// An optimal diff keeps a longest common subsequence.
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		if kept != lcs[0][0] {
+			t.Fatalf("diff of %q and %q keeps %d lines, want %d", a, b, kept, lcs[0][0])
+		}
+	}
+}
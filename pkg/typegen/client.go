@@ -0,0 +1,520 @@
+package typegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+	"github.com/coder/guts/bindings/walk"
+)
+
+// directiveRoute in the doc comment of a handler function adds it to the
+// fetch client: "typegen:route METHOD /path [request=Type] [response=Type]".
+const directiveRoute = "typegen:route"
+
+// defaultClientImport is the module the client imports types from, the
+// default TypeScript output next to it.
+const defaultClientImport = "./index"
+
+// bodyMethods send the request as a JSON body; the others put it in the
+// query string.
+var bodyMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true}
+
+var routeMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+// scannedRoute is a function carrying the route directive.
+type scannedRoute struct {
+	name string
+	// recv is the receiver type name of a method.
+	recv       string
+	importPath string
+	// position is "<pkg>/<file>:<line>".
+	position string
+	// args are the arguments of the directive, which parseDirective checks
+	// and splits into the fields below.
+	args   string
+	method string
+	path   string
+	// request and response name the types given with the directive, as
+	// "Name" or "<import path>.<Name>".
+	request  string
+	response string
+	doc      string
+}
+
+// scanRoute finds the route directive of fn, if any.
+func scanRoute(fn *ast.FuncDecl) (scannedRoute, bool) {
+	if fn.Doc == nil {
+		return scannedRoute{}, false
+	}
+	for _, comment := range fn.Doc.List {
+		args, ok := strings.CutPrefix(comment.Text, "//"+directiveRoute)
+		if !ok || (args != "" && args[0] != ' ' && args[0] != '\t') {
+			continue
+		}
+		route := scannedRoute{
+			name: fn.Name.Name,
+			args: args,
+			doc:  strings.TrimSpace(fn.Doc.Text()),
+		}
+		if fn.Recv != nil && len(fn.Recv.List) == 1 {
+			route.recv = embeddedName(fn.Recv.List[0].Type)
+		}
+		return route, true
+	}
+	return scannedRoute{}, false
+}
+
+// parseDirective parses the arguments of the route directive.
+func (sr *scannedRoute) parseDirective() error {
+	fields := strings.Fields(sr.args)
+	if len(fields) < 2 {
+		return fmt.Errorf("route directive: want METHOD /path")
+	}
+	sr.method = strings.ToUpper(fields[0])
+	sr.path = fields[1]
+	if !routeMethods[sr.method] {
+		return fmt.Errorf("route directive: unknown method %q", fields[0])
+	}
+	if !strings.HasPrefix(sr.path, "/") {
+		return fmt.Errorf("route directive: path %q must start with /", sr.path)
+	}
+	for _, option := range fields[2:] {
+		key, value, _ := strings.Cut(option, "=")
+		switch {
+		case key == "request" && value != "":
+			sr.request = value
+		case key == "response" && value != "":
+			sr.response = value
+		default:
+			return fmt.Errorf("route directive: unknown option %q", option)
+		}
+	}
+	return nil
+}
+
+// route is a handler of the fetch client, with the types of its request and
+// response as they appear in the generated declarations.
+type route struct {
+	name     string
+	position string
+	method   string
+	path     string
+	// params are the wildcard names of path, in order.
+	params []string
+	// request is nil when the handler takes no request, and response when it
+	// returns nothing.
+	request  bindings.ExpressionType
+	response bindings.ExpressionType
+	doc      string
+}
+
+// buildRoutes resolves the request and response types of the scanned routes:
+// from the directive options, or else from the handler signature, leaving out
+// context.Context, http.ResponseWriter, *http.Request and error.
+func buildRoutes(golang *guts.GoParser, mapper *typeMapper, scan *sourceScan, pkgImportPath string) ([]route, error) {
+	routes := make([]route, 0, len(scan.routes))
+	for _, sr := range scan.routes {
+		if err := sr.parseDirective(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", sr.position, sr.name, err)
+		}
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("route %s %s (%s): %s", sr.method, sr.path, sr.position, fmt.Sprintf(format, args...))
+		}
+		pkg, ok := golang.Pkgs[sr.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		fn := lookupFunc(pkg.Types, sr.recv, sr.name)
+		if fn == nil {
+			continue
+		}
+
+		var request, response types.Type
+		sig := fn.Signature()
+		for i := range sig.Params().Len() {
+			param := sig.Params().At(i).Type()
+			if isContext(param) || isHTTPHandlerParam(param) {
+				continue
+			}
+			if request != nil {
+				return nil, fail("%s takes more than one request", sr.name)
+			}
+			request = param
+		}
+		for i := range sig.Results().Len() {
+			result := sig.Results().At(i).Type()
+			if types.Identical(result, types.Universe.Lookup("error").Type()) {
+				continue
+			}
+			if response != nil {
+				return nil, fail("%s returns more than one response", sr.name)
+			}
+			response = result
+		}
+		if sr.request != "" {
+			if request = lookupType(golang, pkg.Types, sr.request); request == nil {
+				return nil, fail("unknown request type %s", sr.request)
+			}
+		}
+		if sr.response != "" {
+			if response = lookupType(golang, pkg.Types, sr.response); response == nil {
+				return nil, fail("unknown response type %s", sr.response)
+			}
+		}
+
+		r := route{
+			name:     sr.name,
+			position: sr.position,
+			method:   sr.method,
+			path:     sr.path,
+			params:   pathParams(sr.path),
+			doc:      sr.doc,
+		}
+		if request != nil {
+			if ptr, ok := request.(*types.Pointer); ok {
+				request = ptr.Elem()
+			}
+			named, ok := request.(*types.Named)
+			if !ok || named.Obj().Pkg() == nil || !isScannedPackage(pkgImportPath, named.Obj().Pkg().Path()) {
+				return nil, fail("request %s is not a struct of the scanned packages", request)
+			}
+			if _, ok := named.Underlying().(*types.Struct); !ok {
+				return nil, fail("request %s is not a struct of the scanned packages", request)
+			}
+			expr, err := mapper.expression(request)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			r.request = expr
+		}
+		if response != nil {
+			expr, err := mapper.expression(response)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			r.response = expr
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// lookupFunc finds the function name, or the method name of the type recv.
+func lookupFunc(pkg *types.Package, recv, name string) *types.Func {
+	if recv == "" {
+		fn, _ := pkg.Scope().Lookup(name).(*types.Func)
+		return fn
+	}
+	tn, ok := pkg.Scope().Lookup(recv).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(tn.Type()), true, pkg, name)
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+// lookupType resolves "Name" in pkg or "<import path>.<Name>" among the
+// loaded packages.
+func lookupType(golang *guts.GoParser, pkg *types.Package, name string) types.Type {
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		loaded, ok := golang.Pkgs[name[:dot]]
+		if !ok || loaded.Types == nil {
+			return nil
+		}
+		pkg, name = loaded.Types, name[dot+1:]
+	}
+	tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	return tn.Type()
+}
+
+func isHTTPHandlerParam(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "net/http" {
+		return false
+	}
+	return named.Obj().Name() == "ResponseWriter" || named.Obj().Name() == "Request"
+}
+
+func isScannedPackage(pkgImportPath, importPath string) bool {
+	return importPath == pkgImportPath || strings.HasPrefix(importPath, pkgImportPath+"/")
+}
+
+// pathParams returns the wildcard names of a ServeMux path, e.g. id and
+// rest of /users/{id}/{rest...}.
+func pathParams(path string) []string {
+	var params []string
+	for segment := range strings.SplitSeq(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, "}")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "...")
+		if name != "$" {
+			params = append(params, name)
+		}
+	}
+	return params
+}
+
+const clientRuntime = `export interface ClientConfig {
+    baseUrl: string;
+    fetch: (input: RequestInfo | URL, init?: RequestInit) => Promise<Response>;
+    headers?: HeadersInit;
+}
+
+export const clientConfig: ClientConfig = {
+    baseUrl: "",
+    fetch: (input: RequestInfo | URL, init?: RequestInit) => fetch(input, init),
+};
+
+export class ClientError extends Error {
+    readonly status: number;
+    readonly body: string;
+
+    constructor(status: number, body: string) {
+        super(` + "`request failed with status ${status}`" + `);
+        this.status = status;
+        this.body = body;
+    }
+}
+
+function pathSegment(value: unknown): string {
+    return encodeURIComponent(String(value));
+}
+
+function pathSegments(value: unknown): string {
+    return String(value).split("/").map(encodeURIComponent).join("/");
+}
+
+function query(params: object): string {
+    const search = new URLSearchParams();
+    for (const [key, value] of Object.entries(params)) {
+        if (value === undefined || value === null) {
+            continue;
+        }
+        for (const item of Array.isArray(value) ? value : [value]) {
+            search.append(key, String(item));
+        }
+    }
+    const text = search.toString();
+    return text ? "?" + text : "";
+}
+
+function omit<T extends object>(value: T, keys: readonly string[]): Partial<T> {
+    return Object.fromEntries(Object.entries(value).filter(([key]) => !keys.includes(key))) as Partial<T>;
+}
+
+async function request<T>(method: string, path: string, body: unknown, init?: RequestInit): Promise<T> {
+    const headers = new Headers(clientConfig.headers);
+    new Headers(init?.headers).forEach((value, key) => headers.set(key, value));
+    if (body !== undefined) {
+        headers.set("Content-Type", "application/json");
+    }
+    const response = await clientConfig.fetch(clientConfig.baseUrl + path, {
+        ...init,
+        method,
+        headers,
+        body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const text = await response.text();
+    if (!response.ok) {
+        throw new ClientError(response.status, text);
+    }
+    return (text ? JSON.parse(text) : undefined) as T;
+}
+`
+
+// GenerateClientWithOptions generates a fetch client with one function per
+// handler carrying the route directive. It imports the request and response
+// types from the output of GenerateTypesWithOptions, see
+// Options.ClientImport.
+func GenerateClientWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderClient(gen)
+}
+
+func renderClient(gen *generation) (string, error) {
+	routes, err := gen.routes()
+	if err != nil {
+		return "", err
+	}
+	routes = slices.Clone(routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].path < routes[j].path
+	})
+
+	var imports []string
+	seen := make(map[string]string, len(routes))
+	var funcs strings.Builder
+	for _, r := range routes {
+		name := applyCasing(r.name, CasingCamel)
+		if other, dup := seen[name]; dup {
+			return "", fmt.Errorf("route %s %s (%s): function %s is also generated for %s", r.method, r.path, r.position, name, other)
+		}
+		seen[name] = r.method + " " + r.path
+
+		request, refs, err := gen.clientType(r.request)
+		if err != nil {
+			return "", fmt.Errorf("route %s %s (%s): request: %w", r.method, r.path, r.position, err)
+		}
+		imports = append(imports, refs...)
+		response, refs, err := gen.clientType(r.response)
+		if err != nil {
+			return "", fmt.Errorf("route %s %s (%s): response: %w", r.method, r.path, r.position, err)
+		}
+		imports = append(imports, refs...)
+		if response == "" {
+			response = "void"
+		}
+
+		var properties map[string]bool
+		if ref, ok := r.request.(*bindings.ReferenceType); ok {
+			properties = gen.properties(gen.rename(ref.Name.Ref()))
+		}
+		for _, param := range r.params {
+			if r.request != nil && !properties[param] {
+				return "", fmt.Errorf("route %s %s (%s): request %s has no %s property", r.method, r.path, r.position, request, param)
+			}
+		}
+		if r.request == nil && len(r.params) > 0 {
+			fields := make([]string, len(r.params))
+			for i, param := range r.params {
+				fields[i] = "readonly " + propertyKey(param) + ": string"
+			}
+			request = "{ " + strings.Join(fields, "; ") + " }"
+		}
+
+		path := r.path
+		for _, param := range r.params {
+			// A {name...} wildcard matches the rest of the path, whose
+			// slashes are kept.
+			wildcard, encode := "{"+param+"}", "pathSegment"
+			if !strings.Contains(path, wildcard) {
+				wildcard, encode = "{"+param+"...}", "pathSegments"
+			}
+			path = strings.Replace(path, wildcard, "${"+encode+"(req."+param+")}", 1)
+		}
+		path = strings.ReplaceAll(path, "{$}", "")
+
+		rest := "req"
+		if len(r.params) > 0 {
+			keys := make([]string, len(r.params))
+			for i, param := range r.params {
+				keys[i] = fmt.Sprintf("%q", param)
+			}
+			rest = "omit(req, [" + strings.Join(keys, ", ") + "])"
+		}
+		body := "undefined"
+		target := "`" + path + "`"
+		switch {
+		case r.request == nil:
+		case bodyMethods[r.method]:
+			body = rest
+		default:
+			target += " + query(" + rest + ")"
+		}
+
+		args := "init?: RequestInit"
+		if request != "" {
+			args = "req: " + request + ", " + args
+		}
+
+		funcs.WriteString("\n")
+		if r.doc != "" {
+			funcs.WriteString("/**\n")
+			for _, line := range strings.Split(r.doc, "\n") {
+				funcs.WriteString(strings.TrimRight(" * "+line, " ") + "\n")
+			}
+			funcs.WriteString(" */\n")
+		}
+		fmt.Fprintf(&funcs, "// %s %s\n", r.method, r.path)
+		fmt.Fprintf(&funcs, "export function %s(%s): Promise<%s> {\n", name, args, response)
+		fmt.Fprintf(&funcs, "    return request<%s>(%q, %s, %s, init);\n}\n", response, r.method, target, body)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by typegen. DO NOT EDIT.\n\n")
+	slices.Sort(imports)
+	imports = slices.Compact(imports)
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "import type { %s } from %q;\n\n", strings.Join(imports, ", "), gen.clientImport)
+	}
+	b.WriteString(clientRuntime)
+	b.WriteString(funcs.String())
+	return b.String(), nil
+}
+
+// clientType renames a copy of typ as the generation renamed its
+// declarations and serializes it, returning the declarations it references.
+// Those must be part of the generated types.
+func (gen *generation) clientType(typ bindings.ExpressionType) (string, []string, error) {
+	if typ == nil {
+		return "", nil, nil
+	}
+	typ = cloneNode(typ).(bindings.ExpressionType)
+	walk.Walk(&identifierRenamer{rename: gen.rename}, typ)
+	refs := referencedKeys(typ)
+	for _, ref := range refs {
+		if _, ok := gen.byName[ref]; !ok {
+			return "", nil, fmt.Errorf("%s is not in the generated types", ref)
+		}
+	}
+	vm, err := bindings.New()
+	if err != nil {
+		return "", nil, err
+	}
+	text, err := serializeExpression(vm, typ)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, refs, nil
+}
+
+// properties returns the property names of the interface declared as name,
+// including those it extends.
+func (gen *generation) properties(name string) map[string]bool {
+	decl, ok := gen.byName[name]
+	if !ok {
+		return nil
+	}
+	iface, ok := decl.node.(*bindings.Interface)
+	if !ok {
+		return nil
+	}
+	properties := make(map[string]bool, len(iface.Fields))
+	for _, field := range iface.Fields {
+		properties[field.Name] = true
+	}
+	for _, heritage := range iface.Heritage {
+		for _, arg := range heritage.Args {
+			if ref, ok := arg.(*bindings.ReferenceType); ok {
+				for property := range gen.properties(ref.Name.Ref()) {
+					properties[property] = true
+				}
+			}
+		}
+	}
+	return properties
+}
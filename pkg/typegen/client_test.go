@@ -0,0 +1,139 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClient_Routes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+import (
+	"context"
+	"net/http"
+)
+
+type GetUserRequest struct {
+	ID     string `+"`json:\"id\"`"+`
+	Expand bool   `+"`json:\"expand\"`"+`
+}
+
+type CreateUserRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type User struct {
+	ID   string `+"`json:\"id\"`"+`
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Handler struct{}
+
+// GetUser returns a single user.
+//
+//typegen:route GET /users/{id}
+func (h *Handler) GetUser(ctx context.Context, req GetUserRequest) (*User, error) {
+	return nil, nil
+}
+
+//typegen:route POST /users
+func (h *Handler) CreateUser(ctx context.Context, req *CreateUserRequest) (User, error) {
+	return User{}, nil
+}
+
+//typegen:route DELETE /users/{id} response=User
+func DeleteUser(w http.ResponseWriter, r *http.Request) {}
+
+//typegen:route GET /files/{path...}
+func GetFile(w http.ResponseWriter, r *http.Request) {}
+
+//typegen:route GET /users
+func ListUsers(ctx context.Context) ([]User, error) {
+	return nil, nil
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateClientWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateClientWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		`import type { CreateUserRequest, GetUserRequest, User } from "./index";`,
+		"/**\n * GetUser returns a single user.\n */\n// GET /users/{id}\n",
+		"export function getUser(req: GetUserRequest, init?: RequestInit): Promise<User | null> {\n" +
+			"    return request<User | null>(\"GET\", `/users/${pathSegment(req.id)}` + query(omit(req, [\"id\"])), undefined, init);\n}",
+		"export function createUser(req: CreateUserRequest, init?: RequestInit): Promise<User> {\n" +
+			"    return request<User>(\"POST\", `/users`, req, init);\n}",
+		"export function deleteUser(req: { readonly id: string }, init?: RequestInit): Promise<User> {",
+		"export function listUsers(init?: RequestInit): Promise<User[]> {",
+		"    return request<void>(\"GET\", `/files/${pathSegments(req.path)}`, undefined, init);\n}",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "typegen:") {
+		t.Fatalf("expected directives to be left out:\n%s", output)
+	}
+
+	opts.IncludeType = "^User$"
+	opts.ClientImport = "./types"
+	if _, err := GenerateClientWithOptions(opts); err == nil || !strings.Contains(err.Error(), "CreateUserRequest is not in the generated types") {
+		t.Fatalf("expected missing request type error, got %v", err)
+	}
+}
+
+func TestGenerateClient_RouteErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type GetUserRequest struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+//typegen:route GET /users/{id}
+func GetUser(req GetUserRequest) {}
+`)
+
+	enterModule(t, root)
+
+	_, err := GenerateClientWithOptions(Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true})
+	if err == nil || !strings.Contains(err.Error(), "request GetUserRequest has no id property") {
+		t.Fatalf("expected missing path parameter error, got %v", err)
+	}
+}
+
+func TestGenerateTypes_IgnoresBrokenRoutes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type User struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+//typegen:route FETCH /users
+func ListUsers() []User { return nil }
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	if _, err := GenerateTypesWithOptions(opts); err != nil {
+		t.Fatalf("expected routes to be left alone without FormatClient, got %v", err)
+	}
+	_, err := GenerateClientWithOptions(opts)
+	if err == nil || !strings.Contains(err.Error(), `ListUsers: route directive: unknown method "FETCH"`) {
+		t.Fatalf("expected unknown method error, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package typegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CollisionStrategy selects what StripPrefix does when declarations of
+// different packages end up with the same name.
+type CollisionStrategy string
+
+const (
+	// CollisionError fails generation, naming every clashing declaration.
+	// It is the default.
+	CollisionError CollisionStrategy = "error"
+	// CollisionPackageSuffix keeps the shortest trailing part of each package
+	// prefix that makes the names unique, e.g. order_Item and cart_Item.
+	CollisionPackageSuffix CollisionStrategy = "package-suffix"
+	// CollisionPascalPrefix prepends the same package path segments in
+	// PascalCase, e.g. OrderItem and CartItem.
+	CollisionPascalPrefix CollisionStrategy = "pascal-prefix"
+)
+
+// NameCollision describes one of several declarations that strip to the
+// same name.
+type NameCollision struct {
+	// Name is the shared name after renames and prefix stripping.
+	Name string
+	// Key is the prefixed identifier, e.g. order_Item.
+	Key string
+	// Package is the import path of the declaring package.
+	Package string
+	// Source is "<pkg>/<file>:<line>", or "<pkg>/<file>" when the line is
+	// unknown.
+	Source string
+}
+
+func validateCollisionStrategy(strategy CollisionStrategy) error {
+	switch strategy {
+	case "", CollisionError, CollisionPackageSuffix, CollisionPascalPrefix:
+		return nil
+	default:
+		return fmt.Errorf("unknown collision strategy %q", strategy)
+	}
+}
+
+// resolveCollisions finds declarations that rename maps to the same name and
+// returns the new names of those that must change, keyed by declaration key.
+func (tree *parsedTree) resolveCollisions(decls []*declaration, rename func(ref string) string, opts Options) (map[string]string, error) {
+	taken := make(map[string]struct{}, len(decls))
+	groups := make(map[string][]*declaration)
+	var names []string
+	for _, decl := range decls {
+		name := rename(decl.key)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], decl)
+		taken[name] = struct{}{}
+	}
+
+	resolved := make(map[string]string)
+	var clashes []string
+	for _, name := range names {
+		group := groups[name]
+		if len(group) < 2 || sameDefinitions(group, rename) {
+			continue
+		}
+
+		collisions := make([]NameCollision, 0, len(group))
+		for _, decl := range group {
+			collisions = append(collisions, tree.collision(name, decl))
+		}
+
+		var next []string
+		switch {
+		case opts.ResolveCollision != nil:
+			for _, c := range collisions {
+				next = append(next, opts.ResolveCollision(c))
+			}
+		case opts.Collisions == CollisionPackageSuffix || opts.Collisions == CollisionPascalPrefix:
+			next = tree.disambiguate(collisions, taken, opts.Collisions)
+		default:
+			clashes = append(clashes, describeCollision(collisions))
+			continue
+		}
+
+		seen := make(map[string]struct{}, len(next))
+		for i, c := range collisions {
+			newName := next[i]
+			if newName == "" {
+				return nil, fmt.Errorf("strip prefix: no name chosen for %s (%s)", c.Key, c.Source)
+			}
+			_, dup := seen[newName]
+			if _, ok := taken[newName]; dup || (ok && newName != name) {
+				return nil, fmt.Errorf("strip prefix: renaming %s (%s) to %s collides with another declaration", c.Key, c.Source, newName)
+			}
+			seen[newName] = struct{}{}
+			resolved[c.Key] = newName
+		}
+		for newName := range seen {
+			taken[newName] = struct{}{}
+		}
+	}
+
+	if len(clashes) > 0 {
+		return nil, fmt.Errorf("strip prefix: %s; rename one of them or choose a collision strategy", strings.Join(clashes, "; "))
+	}
+	return resolved, nil
+}
+
+func (tree *parsedTree) collision(name string, decl *declaration) NameCollision {
+	c := NameCollision{Name: name, Key: decl.key, Source: decl.source}
+	if pkg, ok := tree.packageForKey(decl.key); ok {
+		c.Package = pkg.importPath
+	}
+	if pos, ok := tree.scan.positions[decl.key]; ok {
+		c.Source = pos
+	}
+	return c
+}
+
+func describeCollision(collisions []NameCollision) string {
+	parts := make([]string, 0, len(collisions))
+	for _, c := range collisions {
+		parts = append(parts, fmt.Sprintf("%s (%s)", c.Key, c.Source))
+	}
+	return fmt.Sprintf("%s is declared by %s", collisions[0].Name, strings.Join(parts, " and "))
+}
+
+// disambiguate prefixes every name with the fewest trailing package path
+// segments that make the group unique and free.
+func (tree *parsedTree) disambiguate(collisions []NameCollision, taken map[string]struct{}, strategy CollisionStrategy) []string {
+	segments := make([][]string, len(collisions))
+	depth := 0
+	for i, c := range collisions {
+		rel := strings.TrimPrefix(strings.TrimPrefix(c.Package, tree.pkgImportPath), "/")
+		if rel != "" {
+			segments[i] = strings.Split(rel, "/")
+		}
+		depth = max(depth, len(segments[i]))
+	}
+
+	var next []string
+	for k := 1; k <= depth; k++ {
+		next = next[:0]
+		seen := make(map[string]struct{}, len(collisions))
+		unique := true
+		for i, c := range collisions {
+			tail := segments[i][max(0, len(segments[i])-k):]
+			name := qualifyName(tail, c.Name, strategy)
+			if _, ok := seen[name]; ok {
+				unique = false
+			}
+			if _, ok := taken[name]; ok && name != c.Name {
+				unique = false
+			}
+			seen[name] = struct{}{}
+			next = append(next, name)
+		}
+		if unique {
+			return next
+		}
+	}
+	// Even full package paths clash; let the caller report it.
+	return next
+}
+
+func qualifyName(segments []string, name string, strategy CollisionStrategy) string {
+	if len(segments) == 0 {
+		return name
+	}
+	if strategy == CollisionPascalPrefix {
+		var b strings.Builder
+		for _, segment := range segments {
+			b.WriteString(pascalCase(segment))
+		}
+		return b.String() + name
+	}
+	return prefixForImportPath("", strings.Join(segments, "/")) + name
+}
+
+// pascalCase joins the alphanumeric words of s, capitalizing each.
+func pascalCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// packageForKey returns the scanned package whose prefix key carries.
+func (tree *parsedTree) packageForKey(key string) (packageInfo, bool) {
+	var best packageInfo
+	bestLen := -1
+	for _, pkg := range tree.packages {
+		prefix := prefixForImportPath(tree.pkgImportPath, pkg.importPath)
+		if strings.HasPrefix(key, prefix) && len(prefix) > bestLen {
+			best, bestLen = pkg, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// sameDefinitions reports whether every declaration of group defines the
+// same TypeScript, in which case deduplication may keep any of them.
+func sameDefinitions(group []*declaration, rename func(ref string) string) bool {
+	for _, decl := range group[1:] {
+		if !sameDefinition(group[0].node, decl.node, rename) {
+			return false
+		}
+	}
+	return true
+}
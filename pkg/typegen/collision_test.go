@@ -0,0 +1,82 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_StripPrefixCollisions(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/order/item.go", `package order
+
+type Item struct {
+	Quantity int
+}
+
+type Order struct {
+	Items []Item
+}
+`)
+	writeFile(t, root, "pkg/shop/cart/item.go", `package cart
+
+type Item struct {
+	SKU string
+}
+
+type Cart struct {
+	Items []Item
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		StripPrefix: true,
+	}
+	_, err := GenerateTypesWithOptions(opts)
+	if err == nil {
+		t.Fatalf("expected collision error")
+	}
+	for _, want := range []string{"order/item.go:3", "shop/cart/item.go:3", "Item is declared by"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in error: %v", want, err)
+		}
+	}
+
+	opts.Collisions = CollisionPackageSuffix
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (package-suffix): %v", err)
+	}
+	for _, want := range []string{"interface order_Item ", "interface cart_Item ", "Items: readonly cart_Item[]"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	opts.Collisions = CollisionPascalPrefix
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (pascal-prefix): %v", err)
+	}
+	for _, want := range []string{"interface OrderItem ", "interface CartItem ", "Items: readonly OrderItem[]"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	opts.ResolveCollision = func(c NameCollision) string {
+		return strings.ReplaceAll(strings.TrimPrefix(c.Package, "example.com/test/pkg/"), "/", "_") + "_" + c.Name
+	}
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (callback): %v", err)
+	}
+	if !strings.Contains(output, "interface shop_cart_Item ") || !strings.Contains(output, "interface order_Item ") {
+		t.Fatalf("expected callback names in output:\n%s", output)
+	}
+}
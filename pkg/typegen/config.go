@@ -0,0 +1,268 @@
+package typegen
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames are the file names FindConfig looks for, in order.
+var ConfigFileNames = []string{"typegen.yaml", "typegen.yml", "typegen.json"}
+
+// Config is the content of a typegen.yaml / typegen.json file. Keys match
+// the CLI flag names. Relative paths are resolved against the directory of
+// the config file.
+type Config struct {
+	PkgDir         string `json:"pkg-dir" yaml:"pkg-dir"`
+	PkgPath        string `json:"pkg-path" yaml:"pkg-path"`
+	IncludePattern string `json:"include" yaml:"include"`
+	IncludeType    string `json:"include-type" yaml:"include-type"`
+	StripPrefix    bool   `json:"strip-prefix" yaml:"strip-prefix"`
+	DisableRename  bool   `json:"disable-rename" yaml:"disable-rename"`
+	Strict         bool   `json:"strict" yaml:"strict"`
+	JSONv2         bool   `json:"json-v2" yaml:"json-v2"`
+	Out            string `json:"out" yaml:"out"`
+	Stdout         bool   `json:"stdout" yaml:"stdout"`
+	Format         Format `json:"format" yaml:"format"`
+	// Collisions is the CollisionStrategy used with strip-prefix.
+	Collisions CollisionStrategy `json:"strip-prefix-collisions" yaml:"strip-prefix-collisions"`
+	// FieldTag and FieldCasing choose property names, see Options.FieldTag.
+	FieldTag    string      `json:"field-tag" yaml:"field-tag"`
+	FieldCasing FieldCasing `json:"field-casing" yaml:"field-casing"`
+	// Unions maps interfaces to discriminator fields, see Options.Unions.
+	Unions map[string]string `json:"unions" yaml:"unions"`
+	// ClientImport is where the fetch client imports types from, see
+	// Options.ClientImport.
+	ClientImport string `json:"client-import" yaml:"client-import"`
+	// Services lists interfaces emitted as RPC contracts, see
+	// Options.Services.
+	Services []string `json:"services" yaml:"services"`
+	// Brands and BrandHelpers control branded types, see Options.Brands.
+	Brands       []string `json:"brands" yaml:"brands"`
+	BrandHelpers bool     `json:"brand-helpers" yaml:"brand-helpers"`
+	// Int64 is the Int64Mode of int64 and uint64 values.
+	Int64 Int64Mode `json:"int64" yaml:"int64"`
+	// Mutations names the guts mutations to apply, see Options.Mutations.
+	Mutations []string `json:"mutations" yaml:"mutations"`
+	// Nullability chooses how fields that may be empty are typed, see
+	// Options.Nullability.
+	Nullability Nullability `json:"nullability" yaml:"nullability"`
+	// Naming selects built-in naming strategies, see Options.Namer.
+	Naming *NamingConfig `json:"naming" yaml:"naming"`
+	// TypeMappings maps fully qualified Go types to TypeScript types, see
+	// Options.TypeMappings.
+	TypeMappings map[string]string `json:"type-mappings" yaml:"type-mappings"`
+	// Targets, when set, replace the single output with several outputs
+	// generated from one parse.
+	Targets []TargetConfig `json:"targets" yaml:"targets"`
+
+	// dir is the directory holding the config file.
+	dir string
+	// namer is built from Naming when the config is loaded.
+	namer Namer
+}
+
+// NamingConfig configures the built-in naming strategies. The prefix is
+// applied before the suffix rewrites.
+type NamingConfig struct {
+	// Prefix is "package" for PackagePrefixNamer or "last-segment" for
+	// LastSegmentNamer.
+	Prefix   string            `json:"prefix" yaml:"prefix"`
+	Suffixes map[string]string `json:"suffixes" yaml:"suffixes"`
+}
+
+// Namer builds the Namer described by the config.
+func (n *NamingConfig) Namer() (Namer, error) {
+	var namers []Namer
+	switch n.Prefix {
+	case "":
+	case "package":
+		namers = append(namers, PackagePrefixNamer)
+	case "last-segment":
+		namers = append(namers, LastSegmentNamer)
+	default:
+		return nil, fmt.Errorf("unknown naming prefix %q, use package or last-segment", n.Prefix)
+	}
+	if len(n.Suffixes) > 0 {
+		namers = append(namers, SuffixNamer(n.Suffixes))
+	}
+	if len(namers) == 0 {
+		return nil, nil
+	}
+	return ChainNamers(namers...), nil
+}
+
+// TargetConfig is one entry of the targets list. Unset whitelist, rename,
+// field naming and format settings are inherited from the top level of the
+// config.
+type TargetConfig struct {
+	Name           string `json:"name" yaml:"name"`
+	IncludePattern string `json:"include" yaml:"include"`
+	IncludeType    string `json:"include-type" yaml:"include-type"`
+	StripPrefix    *bool  `json:"strip-prefix" yaml:"strip-prefix"`
+	DisableRename  *bool  `json:"disable-rename" yaml:"disable-rename"`
+	Out            string `json:"out" yaml:"out"`
+	Stdout         bool   `json:"stdout" yaml:"stdout"`
+	Format         Format `json:"format" yaml:"format"`
+	// FieldTag and FieldCasing let targets use different wire formats.
+	FieldTag    string      `json:"field-tag" yaml:"field-tag"`
+	FieldCasing FieldCasing `json:"field-casing" yaml:"field-casing"`
+}
+
+// FindConfig looks for a config file next to the go.mod that governs
+// startDir. It returns "" without error when there is none.
+func FindConfig(startDir string) (string, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", fmt.Errorf("abs start directory: %w", err)
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !info.IsDir() {
+			for _, name := range ConfigFileNames {
+				candidate := filepath.Join(dir, name)
+				if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+					return candidate, nil
+				}
+			}
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads a YAML or JSON config file, chosen by extension. Unknown
+// keys are rejected.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	cfg := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("config %s: unsupported extension, use .yaml, .yml or .json", path)
+	}
+
+	if cfg.Naming != nil {
+		if cfg.namer, err = cfg.Naming.Namer(); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("abs config path: %w", err)
+	}
+	cfg.dir = filepath.Dir(abs)
+	return cfg, nil
+}
+
+// Preset returns the reusable part of the config.
+func (c *Config) Preset() Preset {
+	return Preset{
+		IncludePattern: c.IncludePattern,
+		IncludeType:    c.IncludeType,
+		StripPrefix:    c.StripPrefix,
+		DisableRename:  c.DisableRename,
+	}
+}
+
+// Options builds generator options from the config.
+func (c *Config) Options() Options {
+	opts := c.Preset().Options(c.resolve(c.PkgDir), c.PkgPath)
+	opts.Strict = c.Strict
+	opts.Collisions = c.Collisions
+	opts.TypeMappings = c.TypeMappings
+	opts.JSONv2 = c.JSONv2
+	opts.Nullability = c.Nullability
+	opts.Mutations = c.Mutations
+	opts.Int64 = c.Int64
+	opts.Brands = c.Brands
+	opts.Unions = c.Unions
+	opts.Services = c.Services
+	opts.ClientImport = c.ClientImport
+	opts.BrandHelpers = c.BrandHelpers
+	opts.Namer = c.namer
+	opts.FieldTag = c.FieldTag
+	opts.FieldCasing = c.FieldCasing
+	return opts
+}
+
+// OutputOptions builds output options from the config.
+func (c *Config) OutputOptions() OutputOptions {
+	out := c.Out
+	if out != "-" {
+		out = c.resolve(out)
+	}
+	return OutputOptions{
+		OutputPath: out,
+		Stdout:     c.Stdout,
+		Format:     c.Format,
+	}
+}
+
+func (c *Config) resolve(p string) string {
+	if p == "" || filepath.IsAbs(p) || c.dir == "" {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+// OutputTargets builds the targets list for GenerateTargets, resolving
+// inherited settings and relative output paths.
+func (c *Config) OutputTargets() []Target {
+	targets := make([]Target, 0, len(c.Targets))
+	for _, tc := range c.Targets {
+		t := Target{
+			Name:           tc.Name,
+			IncludePattern: cmp.Or(tc.IncludePattern, c.IncludePattern),
+			IncludeType:    cmp.Or(tc.IncludeType, c.IncludeType),
+			StripPrefix:    c.StripPrefix,
+			DisableRename:  c.DisableRename,
+			FieldTag:       cmp.Or(tc.FieldTag, c.FieldTag),
+			FieldCasing:    cmp.Or(tc.FieldCasing, c.FieldCasing),
+			Output: OutputOptions{
+				OutputPath: tc.Out,
+				Stdout:     tc.Stdout,
+				Format:     cmp.Or(tc.Format, c.Format),
+			},
+		}
+		if tc.StripPrefix != nil {
+			t.StripPrefix = *tc.StripPrefix
+		}
+		if tc.DisableRename != nil {
+			t.DisableRename = *tc.DisableRename
+		}
+		if t.Output.OutputPath != "-" {
+			t.Output.OutputPath = c.resolve(t.Output.OutputPath)
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
@@ -0,0 +1,54 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_ResolvesPathsAndRejectsUnknownKeys(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+	writeFile(t, root, "typegen.yaml", `pkg-dir: ./pkg
+out: web/index.d.ts
+include-type: 'Req$'
+strip-prefix: true
+format: zod
+type-mappings:
+  database/sql.Null[T]: T | null
+naming:
+  prefix: last-segment
+  suffixes: {Req: Request}
+`)
+
+	path, err := FindConfig(filepath.Join(root, "pkg"))
+	if err != nil {
+		t.Fatalf("FindConfig: %v", err)
+	}
+	if path != filepath.Join(root, "typegen.yaml") {
+		t.Fatalf("expected config next to go.mod, got %q", path)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	opts := cfg.Options()
+	if opts.PkgDir != filepath.Join(root, "pkg") || opts.IncludeType != "Req$" || !opts.StripPrefix ||
+		opts.TypeMappings["database/sql.Null[T]"] != "T | null" || opts.Namer == nil {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if got := opts.Namer(NameContext{Name: "ListReq", Package: "order/admin"}); got != "AdminListRequest" {
+		t.Fatalf("unexpected config namer result %q", got)
+	}
+	output := cfg.OutputOptions()
+	if output.OutputPath != filepath.Join(root, "web", "index.d.ts") || output.Format != FormatZod {
+		t.Fatalf("unexpected output options: %+v", output)
+	}
+
+	writeFile(t, root, "typegen.json", `{"pkg-dir": "./pkg", "strip-prefixes": true}`)
+	_, err = LoadConfig(filepath.Join(root, "typegen.json"))
+	if err == nil || !strings.Contains(err.Error(), "strip-prefixes") {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package typegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coder/guts"
+)
+
+// directiveConst in the doc comment of an untyped constant, or of the const
+// block holding it, emits it as a value with FormatConstants.
+const directiveConst = "typegen:const"
+
+// scannedConst is an exported constant carrying the const directive.
+type scannedConst struct {
+	name       string
+	importPath string
+	// position is "<pkg>/<file>:<line>".
+	position string
+	doc      string
+}
+
+// scanConsts returns the exported names of spec when it or its block carries
+// the const directive.
+func scanConsts(genDecl *ast.GenDecl, spec *ast.ValueSpec) []scannedConst {
+	if !hasConstDirective(spec.Doc) && !hasConstDirective(genDecl.Doc) {
+		return nil
+	}
+	doc := spec.Doc
+	if doc == nil && len(genDecl.Specs) == 1 {
+		doc = genDecl.Doc
+	}
+	var consts []scannedConst
+	for _, name := range spec.Names {
+		if name.IsExported() {
+			consts = append(consts, scannedConst{name: name.Name, doc: strings.TrimSpace(doc.Text())})
+		}
+	}
+	return consts
+}
+
+func hasConstDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(comment.Text) == "//"+directiveConst {
+			return true
+		}
+	}
+	return false
+}
+
+// constValue is a constant with its value as a TypeScript literal.
+type constValue struct {
+	name    string
+	literal string
+	doc     string
+}
+
+// collectEnumValues returns the exported constants of every enum, keyed by
+// the enum's declaration key, in source order. Names lose the enum name as
+// a prefix: StatusActive of Status becomes Active.
+func collectEnumValues(golang *guts.GoParser, scan *sourceScan) map[string][]constValue {
+	enums := make(map[string][]constValue)
+	for _, st := range scan.types {
+		if st.kind != KindEnum {
+			continue
+		}
+		pkg, ok := golang.Pkgs[st.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		tn, ok := pkg.Types.Scope().Lookup(st.name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+
+		var consts []*types.Const
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			c, ok := scope.Lookup(name).(*types.Const)
+			if ok && c.Exported() && types.Identical(c.Type(), tn.Type()) {
+				consts = append(consts, c)
+			}
+		}
+		sort.Slice(consts, func(i, j int) bool {
+			return consts[i].Pos() < consts[j].Pos()
+		})
+
+		seen := make(map[string]bool, len(consts))
+		for _, c := range consts {
+			literal, ok := constLiteral(c.Val())
+			if !ok {
+				continue
+			}
+			name := strings.TrimPrefix(c.Name(), st.name)
+			if name == "" || !isIdentifier(name) || seen[name] {
+				name = c.Name()
+			}
+			seen[name] = true
+			enums[st.key] = append(enums[st.key], constValue{name: name, literal: literal})
+		}
+	}
+	return enums
+}
+
+// collectConstants resolves the constants carrying the const directive,
+// which must be untyped strings or numbers.
+func collectConstants(golang *guts.GoParser, scan *sourceScan) ([]constValue, error) {
+	seen := make(map[string]string, len(scan.constants))
+	var consts []constValue
+	for _, sc := range scan.constants {
+		pkg, ok := golang.Pkgs[sc.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		c, ok := pkg.Types.Scope().Lookup(sc.name).(*types.Const)
+		if !ok {
+			continue
+		}
+		basic, ok := c.Type().(*types.Basic)
+		if !ok || basic.Info()&types.IsUntyped == 0 || basic.Info()&(types.IsString|types.IsNumeric) == 0 {
+			return nil, fmt.Errorf("const %s (%s): only untyped string and numeric constants can be exported", sc.name, sc.position)
+		}
+		literal, ok := constLiteral(c.Val())
+		if !ok {
+			return nil, fmt.Errorf("const %s (%s): %s has no TypeScript literal", sc.name, sc.position, c.Val())
+		}
+		if other, dup := seen[sc.name]; dup {
+			return nil, fmt.Errorf("const %s (%s): also declared at %s", sc.name, sc.position, other)
+		}
+		seen[sc.name] = sc.position
+		consts = append(consts, constValue{name: sc.name, literal: literal, doc: sc.doc})
+	}
+	sort.Slice(consts, func(i, j int) bool {
+		return consts[i].name < consts[j].name
+	})
+	return consts, nil
+}
+
+// constLiteral prints a string or real number constant as a TypeScript
+// literal.
+func constLiteral(value constant.Value) (string, bool) {
+	switch value.Kind() {
+	case constant.String:
+		return strconv.Quote(constant.StringVal(value)), true
+	case constant.Int:
+		return value.ExactString(), true
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// GenerateConstantsWithOptions generates a .ts module with the runtime
+// values of the declarations that GenerateTypesWithOptions would emit: an
+// object and a list of values per enum, and the constants carrying the const
+// directive.
+func GenerateConstantsWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderConstants(gen)
+}
+
+func renderConstants(gen *generation) (string, error) {
+	var b strings.Builder
+	b.WriteString("// Code generated by typegen. DO NOT EDIT.\n")
+
+	for _, decl := range gen.decls {
+		values, ok := gen.enums[decl.key]
+		if !ok {
+			continue
+		}
+		literals := make([]string, len(values))
+		fmt.Fprintf(&b, "\n// From %s\n", decl.source)
+		fmt.Fprintf(&b, "export const %s = {\n", decl.name)
+		for i, value := range values {
+			fmt.Fprintf(&b, "    %s: %s,\n", propertyKey(value.name), value.literal)
+			literals[i] = value.literal
+		}
+		b.WriteString("} as const;\n\n")
+		fmt.Fprintf(&b, "export const %sValues = [%s] as const;\n", decl.name, strings.Join(literals, ", "))
+	}
+
+	constants, err := gen.constants()
+	if err != nil {
+		return "", err
+	}
+	for _, c := range constants {
+		b.WriteString("\n")
+		if c.doc != "" {
+			b.WriteString("/**\n")
+			for _, line := range strings.Split(c.doc, "\n") {
+				b.WriteString(strings.TrimRight(" * "+line, " ") + "\n")
+			}
+			b.WriteString(" */\n")
+		}
+		fmt.Fprintf(&b, "export const %s = %s;\n", c.name, c.literal)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,128 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateConstants_EnumsAndDirectives(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+	Banned         Status = "banned"
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityHigh
+)
+
+type User struct {
+	Status   Status   `+"`json:\"status\"`"+`
+	Priority Priority `+"`json:\"priority\"`"+`
+}
+
+// MaxPageSize caps list requests.
+//
+//typegen:const
+const MaxPageSize = 100
+
+//typegen:const
+const (
+	APIVersion = "v2"
+	Ratio      = 0.5
+)
+
+const Hidden = "hidden"
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateConstantsWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateConstantsWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"export const Status = {\n    Active: \"active\",\n    Inactive: \"inactive\",\n    Banned: \"banned\",\n} as const;\n",
+		"export const StatusValues = [\"active\", \"inactive\", \"banned\"] as const;\n",
+		"export const Priority = {\n    Low: 1,\n    High: 2,\n} as const;\n",
+		"export const PriorityValues = [1, 2] as const;\n",
+		"/**\n * MaxPageSize caps list requests.\n */\nexport const MaxPageSize = 100;\n",
+		"export const APIVersion = \"v2\";\n",
+		"export const Ratio = 0.5;\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Hidden") || strings.Contains(output, "typegen:") {
+		t.Fatalf("expected unmarked constants and directives to be left out:\n%s", output)
+	}
+
+	opts.IncludeType = "^Priority$"
+	output, err = GenerateConstantsWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateConstantsWithOptions (include-type): %v", err)
+	}
+	if strings.Contains(output, "StatusValues") || !strings.Contains(output, "PriorityValues") {
+		t.Fatalf("expected only the selected enums:\n%s", output)
+	}
+}
+
+func TestGenerateConstants_TypedConstantError(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+//typegen:const
+const Limit int = 10
+`)
+
+	enterModule(t, root)
+
+	_, err := GenerateConstantsWithOptions(Options{PkgDir: filepath.Join(root, "pkg")})
+	if err == nil || !strings.Contains(err.Error(), "only untyped string and numeric constants") {
+		t.Fatalf("expected typed constant error, got %v", err)
+	}
+}
+
+func TestGenerateTypes_IgnoresBrokenConstants(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type User struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+//typegen:const
+const Max int = 10
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("expected constants to be left alone without FormatConstants, got %v", err)
+	}
+	if !strings.Contains(output, "export interface User {") {
+		t.Fatalf("expected User in output:\n%s", output)
+	}
+	if _, err := GenerateConstantsWithOptions(opts); err == nil {
+		t.Fatal("expected the typed constant to fail FormatConstants")
+	}
+}
@@ -0,0 +1,427 @@
+package typegen
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+	"github.com/coder/guts/bindings/walk"
+)
+
+// declaration is a top-level node of the generated TypeScript together with
+// the metadata the post-processing passes work on. Passes run on the guts AST
+// before serialization, so they only ever touch real identifiers.
+type declaration struct {
+	// key is the guts node key: the Go name with its package prefix.
+	key string
+	// name is the exported TypeScript identifier after rename and prefix
+	// stripping. It equals key until renameDeclarations runs.
+	name string
+	node bindings.Node
+	// source is "<pkg>/<file>", as printed in the "From" header.
+	source string
+	// refs identify the declarations referenced by node: keys until
+	// renameDeclarations runs, names afterwards.
+	refs []string
+	// rpc marks an RPC contract, see buildServices.
+	rpc bool
+	// omitempty holds the types of json omitempty properties before
+	// applyNullability, by property name, for renameProperties to redo the
+	// policies when another field tag decides omitempty.
+	omitempty map[string]bindings.ExpressionType
+}
+
+// collectDeclarations returns every node of ts sorted by key, which is the
+// order guts serializes in.
+func collectDeclarations(ts *guts.Typescript) []*declaration {
+	var decls []*declaration
+	ts.ForEach(func(key string, node bindings.Node) {
+		decls = append(decls, &declaration{
+			key:    key,
+			name:   key,
+			node:   node,
+			source: declarationSource(node),
+			refs:   referencedKeys(node),
+		})
+	})
+
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].key < decls[j].key
+	})
+	return decls
+}
+
+// cloneDeclarations deep-copies decls so that renaming one generation does
+// not leak into others derived from the same parse.
+func cloneDeclarations(decls []*declaration) []*declaration {
+	cloned := make([]*declaration, 0, len(decls))
+	for _, decl := range decls {
+		cloned = append(cloned, &declaration{
+			key:    decl.key,
+			name:   decl.name,
+			node:   cloneNode(decl.node),
+			source: decl.source,
+			refs:   slices.Clone(decl.refs),
+			rpc:    decl.rpc,
+			// Only read after parse, so the map is shared.
+			omitempty: decl.omitempty,
+		})
+	}
+	return cloned
+}
+
+var bindingsPkgPath = reflect.TypeOf(bindings.Identifier{}).PkgPath()
+
+// cloneNode deep-copies the bindings structures reachable from node. Values
+// owned by other packages, such as the *types.Package of an identifier, are
+// shared.
+func cloneNode(node bindings.Node) bindings.Node {
+	return cloneValue(reflect.ValueOf(node)).Interface().(bindings.Node)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Type().Elem().PkgPath() != bindingsPkgPath {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := range v.NumField() {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// serializeDeclarations prints decls in order after header, like guts'
+// SerializeInOrder but without consuming a guts.Typescript, which can only
+// serialize once. With helpers, branded types are followed by their
+// constructor function.
+func serializeDeclarations(header string, decls []*declaration, helpers bool) (string, error) {
+	vm, err := bindings.New()
+	if err != nil {
+		return "", fmt.Errorf("create typescript bindings: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for _, decl := range decls {
+		if decl.rpc {
+			text, err := serializeService(vm, decl)
+			if err != nil {
+				return "", fmt.Errorf("serialize %q: %w", decl.name, err)
+			}
+			b.WriteString(text + "\n")
+			continue
+		}
+		obj, err := vm.ToTypescriptNode(decl.node)
+		if err != nil {
+			return "", fmt.Errorf("convert node %q: %w", decl.name, err)
+		}
+		text, err := vm.SerializeToTypescript(obj)
+		if err != nil {
+			return "", fmt.Errorf("serialize %q: %w", decl.name, err)
+		}
+		b.WriteString(hoistLintComments(text) + "\n\n")
+		if helper := brandedTypeHelper(decl); helpers && helper != "" {
+			b.WriteString(helper + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// hoistLintComments moves the leading comments of a declaration other than
+// its "From" line and doc comment, such as biome-ignore lines, above them.
+// The text-based pipeline this one replaced printed them there, and
+// committed outputs should not change because of it.
+func hoistLintComments(text string) string {
+	lines := strings.Split(text, "\n")
+	var hoisted, kept []string
+	for i, line := range lines {
+		if strings.HasPrefix(line, "export ") {
+			return strings.Join(slices.Concat(hoisted, kept, lines[i:]), "\n")
+		}
+		if strings.HasPrefix(line, "// From ") || strings.HasPrefix(line, "/**") || strings.HasPrefix(line, " *") {
+			kept = append(kept, line)
+		} else {
+			hoisted = append(hoisted, line)
+		}
+	}
+	return text
+}
+
+func declarationSource(node bindings.Node) string {
+	switch node := node.(type) {
+	case *bindings.Interface:
+		return node.Source.File
+	case *bindings.Alias:
+		return node.Source.File
+	case *bindings.Enum:
+		return node.Source.File
+	case *bindings.VariableStatement:
+		return node.Source.File
+	}
+	return ""
+}
+
+// excludeDeclarations drops declarations whose key is in excluded.
+func excludeDeclarations(decls []*declaration, excluded map[string]struct{}) []*declaration {
+	if len(excluded) == 0 {
+		return decls
+	}
+	kept := decls[:0:0]
+	for _, decl := range decls {
+		if _, skip := excluded[decl.key]; !skip {
+			kept = append(kept, decl)
+		}
+	}
+	return kept
+}
+
+// selectDeclarations keeps the declarations whose source matches fileRegexp
+// and whose matchName matches typeRegexp, plus everything they reference.
+func selectDeclarations(decls []*declaration, fileRegexp, typeRegexp *regexp.Regexp, matchName func(key string) string) []*declaration {
+	if fileRegexp == nil && typeRegexp == nil {
+		return decls
+	}
+
+	byKey := make(map[string]*declaration, len(decls))
+	for _, decl := range decls {
+		byKey[decl.key] = decl
+	}
+
+	selected := make(map[string]struct{})
+	var queue []string
+	for _, decl := range decls {
+		if fileRegexp != nil && !fileRegexp.MatchString(decl.source) {
+			continue
+		}
+		if typeRegexp != nil && !typeRegexp.MatchString(matchName(decl.key)) {
+			continue
+		}
+		selected[decl.key] = struct{}{}
+		queue = append(queue, decl.key)
+	}
+
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		decl, ok := byKey[key]
+		if !ok {
+			continue
+		}
+		for _, ref := range decl.refs {
+			if _, ok := selected[ref]; ok {
+				continue
+			}
+			if _, ok := byKey[ref]; !ok {
+				continue
+			}
+			selected[ref] = struct{}{}
+			queue = append(queue, ref)
+		}
+	}
+
+	kept := decls[:0:0]
+	for _, decl := range decls {
+		if _, ok := selected[decl.key]; ok {
+			kept = append(kept, decl)
+		}
+	}
+	return kept
+}
+
+// renameDeclarations rewrites every identifier in decls (declaration names,
+// references and type parameters) through rename. String literal types and
+// comments are not identifiers and are left untouched.
+func renameDeclarations(decls []*declaration, rename func(ref string) string) {
+	v := &identifierRenamer{rename: rename}
+	for _, decl := range decls {
+		decl.name = rename(decl.key)
+		for i, ref := range decl.refs {
+			decl.refs[i] = rename(ref)
+		}
+		walk.Walk(v, decl.node)
+	}
+}
+
+type identifierRenamer struct {
+	rename func(ref string) string
+}
+
+func (v *identifierRenamer) Visit(node bindings.Node) walk.Visitor {
+	switch node := node.(type) {
+	case *bindings.Interface:
+		v.identifier(&node.Name)
+	case *bindings.Alias:
+		v.identifier(&node.Name)
+		// walk does not descend into alias type parameters.
+		for _, param := range node.Parameters {
+			walk.Walk(v, param)
+		}
+	case *bindings.Enum:
+		v.identifier(&node.Name)
+	case *bindings.TypeParameter:
+		v.identifier(&node.Name)
+	case *bindings.ReferenceType:
+		v.identifier(&node.Name)
+	case *bindings.VariableDeclaration:
+		v.identifier(&node.Name)
+	}
+	return v
+}
+
+func (v *identifierRenamer) identifier(id *bindings.Identifier) {
+	ref := id.Ref()
+	if next := v.rename(ref); next != ref {
+		id.Name = next
+		id.Prefix = ""
+	}
+}
+
+// declarationConflict is a declaration dropped by deduplicateDeclarations
+// whose definition differs from the one kept under the same name.
+type declarationConflict struct {
+	kept    *declaration
+	dropped *declaration
+}
+
+// deduplicateDeclarations keeps the first declaration for each name. Later
+// declarations with a different definition are reported as conflicts.
+func deduplicateDeclarations(decls []*declaration) ([]*declaration, []declarationConflict) {
+	seen := make(map[string]*declaration, len(decls))
+	kept := decls[:0:0]
+	var conflicts []declarationConflict
+	for _, decl := range decls {
+		if first, ok := seen[decl.name]; ok {
+			if !sameDefinition(first.node, decl.node, nil) {
+				conflicts = append(conflicts, declarationConflict{kept: first, dropped: decl})
+			}
+			continue
+		}
+		seen[decl.name] = decl
+		kept = append(kept, decl)
+	}
+	return kept, conflicts
+}
+
+var (
+	identifierType = reflect.TypeOf(bindings.Identifier{})
+	sourceType     = reflect.TypeOf(bindings.Source{})
+)
+
+// sameDefinition reports whether a and b declare the same TypeScript. Source
+// files and comments are ignored, and identifiers are compared by the name
+// rename gives them, or by their current name when rename is nil.
+func sameDefinition(a, b bindings.Node, rename func(ref string) string) bool {
+	if rename == nil {
+		rename = func(ref string) string { return ref }
+	}
+	return sameValue(reflect.ValueOf(a), reflect.ValueOf(b), rename)
+}
+
+func sameValue(a, b reflect.Value, rename func(ref string) string) bool {
+	if a.IsValid() != b.IsValid() {
+		return false
+	}
+	if !a.IsValid() {
+		return true
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return sameValue(a.Elem(), b.Elem(), rename)
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := range a.Len() {
+			if !sameValue(a.Index(i), b.Index(i), rename) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		switch a.Type() {
+		case identifierType:
+			idA := a.Interface().(bindings.Identifier)
+			idB := b.Interface().(bindings.Identifier)
+			return rename(idA.Ref()) == rename(idB.Ref())
+		case sourceType:
+			return true
+		}
+		for i := range a.NumField() {
+			if !a.Type().Field(i).IsExported() {
+				continue
+			}
+			if !sameValue(a.Field(i), b.Field(i), rename) {
+				return false
+			}
+		}
+		return true
+	default:
+		if !a.CanInterface() {
+			return true
+		}
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
+
+// referencedKeys returns the identifiers referenced by node, in walk order.
+func referencedKeys(node bindings.Node) []string {
+	v := &referenceCollector{seen: make(map[string]struct{})}
+	walk.Walk(v, node)
+	return v.keys
+}
+
+type referenceCollector struct {
+	seen map[string]struct{}
+	keys []string
+}
+
+func (v *referenceCollector) Visit(node bindings.Node) walk.Visitor {
+	if ref, ok := node.(*bindings.ReferenceType); ok {
+		key := ref.Name.Ref()
+		if _, ok := v.seen[key]; !ok {
+			v.seen[key] = struct{}{}
+			v.keys = append(v.keys, key)
+		}
+	}
+	return v
+}
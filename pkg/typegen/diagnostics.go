@@ -0,0 +1,122 @@
+package typegen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DiagnosticKind classifies why a package is missing from, or incomplete in,
+// the generated output.
+type DiagnosticKind string
+
+const (
+	// DiagnosticNoGoFiles reports a package directory whose Go files are all
+	// excluded, typically by build constraints. Nothing is generated for it.
+	DiagnosticNoGoFiles DiagnosticKind = "no Go files"
+	// DiagnosticLoadFailed reports a package that could not be loaded or
+	// type-checked. Its types are missing or incomplete.
+	DiagnosticLoadFailed DiagnosticKind = "failed to load"
+	// DiagnosticConflict reports a declaration that was dropped because
+	// another package declares the same name with a different definition.
+	DiagnosticConflict DiagnosticKind = "conflicting definition"
+)
+
+// Diagnostic describes one package that was skipped or loaded with errors,
+// or whose declaration was dropped in favor of a conflicting one.
+type Diagnostic struct {
+	Kind DiagnosticKind
+	// Package is the import path of the package.
+	Package string
+	// Dir is the directory holding the package.
+	Dir string
+	// Err holds the underlying loader or compiler errors.
+	Err error
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %v", d.Package, d.Kind, d.Err)
+}
+
+// Diagnostics is the list of problems found while loading packages and
+// generating their declarations.
+type Diagnostics []Diagnostic
+
+// Failed returns the diagnostics that make the output incomplete, i.e.
+// everything except packages without Go files.
+func (d Diagnostics) Failed() Diagnostics {
+	var failed Diagnostics
+	for _, diag := range d {
+		if diag.Kind != DiagnosticNoGoFiles {
+			failed = append(failed, diag)
+		}
+	}
+	return failed
+}
+
+// DiagnosticsError is returned in strict mode when packages failed to load
+// or declarations conflict.
+type DiagnosticsError struct {
+	Diagnostics Diagnostics
+}
+
+func (e *DiagnosticsError) Error() string {
+	conflicts := 0
+	for _, diag := range e.Diagnostics {
+		if diag.Kind == DiagnosticConflict {
+			conflicts++
+		}
+	}
+	var summary []string
+	if failed := len(e.Diagnostics) - conflicts; failed > 0 {
+		summary = append(summary, fmt.Sprintf("%d package(s) failed to load", failed))
+	}
+	if conflicts > 0 {
+		summary = append(summary, fmt.Sprintf("%d conflicting definition(s)", conflicts))
+	}
+
+	lines := make([]string, 0, len(e.Diagnostics)+1)
+	lines = append(lines, strings.Join(summary, ", "))
+	for _, diag := range e.Diagnostics {
+		lines = append(lines, "  "+diag.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// packageDiagnostic classifies the errors reported by the loader for pkg.
+func packageDiagnostic(pkg packageInfo, errs []error) Diagnostic {
+	kind := DiagnosticLoadFailed
+	if len(errs) > 0 {
+		kind = DiagnosticNoGoFiles
+		for _, err := range errs {
+			msg := err.Error()
+			if !strings.Contains(msg, "no Go files") && !strings.Contains(msg, "build constraints exclude all Go files") {
+				kind = DiagnosticLoadFailed
+				break
+			}
+		}
+	}
+	return Diagnostic{
+		Kind:    kind,
+		Package: pkg.importPath,
+		Dir:     pkg.dir,
+		Err:     errors.Join(errs...),
+	}
+}
+
+// conflictDiagnostic reports the dropped side of a conflict, naming the Go
+// declarations of both.
+func (tree *parsedTree) conflictDiagnostic(c declarationConflict) Diagnostic {
+	kept := tree.collision(c.kept.name, c.kept)
+	dropped := tree.collision(c.dropped.name, c.dropped)
+	diag := Diagnostic{
+		Kind:    DiagnosticConflict,
+		Package: dropped.Package,
+		Err: fmt.Errorf("%s from %s differs from the %s kept from %s",
+			dropped.Key, dropped.Source, c.kept.name, kept.Source),
+	}
+	if pkg, ok := tree.packageForKey(c.dropped.key); ok {
+		diag.Dir = pkg.dir
+	}
+	return diag
+}
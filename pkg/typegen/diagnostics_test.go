@@ -0,0 +1,87 @@
+package typegen
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_ReportsSkippedPackages(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/ok/a.go", "package ok\n\ntype A struct{ X int }\n")
+	writeFile(t, root, "pkg/broken/b.go", "package broken\n\ntype B struct{ X Missing }\n")
+	writeFile(t, root, "pkg/tagged/c.go", "//go:build ignore\n\npackage tagged\n\ntype C struct{}\n")
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg")}
+	output, diags, err := GenerateTypesWithDiagnostics(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithDiagnostics: %v", err)
+	}
+	if !strings.Contains(output, "export interface A ") {
+		t.Fatalf("expected healthy package in output:\n%s", output)
+	}
+
+	kinds := make(map[string]DiagnosticKind)
+	for _, diag := range diags {
+		kinds[diag.Package] = diag.Kind
+	}
+	if kinds["example.com/test/pkg/broken"] != DiagnosticLoadFailed {
+		t.Fatalf("expected broken package to fail loading, got %v", diags)
+	}
+	if kinds["example.com/test/pkg/tagged"] != DiagnosticNoGoFiles {
+		t.Fatalf("expected tagged package to have no Go files, got %v", diags)
+	}
+
+	opts.Strict = true
+	_, err = GenerateTypesWithOptions(opts)
+	var diagErr *DiagnosticsError
+	if !errors.As(err, &diagErr) {
+		t.Fatalf("expected DiagnosticsError in strict mode, got %v", err)
+	}
+	if len(diagErr.Diagnostics) != 1 || !strings.Contains(diagErr.Error(), "undefined: Missing") {
+		t.Fatalf("expected only the broken package to fail strict mode: %v", diagErr)
+	}
+}
+
+func TestGenerateTypes_ReportsConflictingDefinitions(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/item.go", "package pkg\n\ntype Item struct{ ID int }\n\ntype Page struct{ Size int }\n")
+	writeFile(t, root, "pkg/order/item.go", "package order\n\ntype Item struct{ Quantity int }\n")
+	writeFile(t, root, "pkg/cart/page.go", "package cart\n\ntype Page struct{ Size int }\n")
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg")}
+	output, diags, err := GenerateTypesWithDiagnostics(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithDiagnostics: %v", err)
+	}
+	if !strings.Contains(output, "readonly ID: number;") || strings.Contains(output, "Quantity") {
+		t.Fatalf("expected the first Item to be kept:\n%s", output)
+	}
+	if len(diags) != 1 || diags[0].Kind != DiagnosticConflict || diags[0].Package != "example.com/test/pkg/order" {
+		t.Fatalf("expected one conflict for order.Item (identical Page is fine), got %v", diags)
+	}
+	if msg := diags[0].String(); !strings.Contains(msg, "order/item.go:3") || !strings.Contains(msg, "kept from item.go:3") {
+		t.Fatalf("expected both Go origins in %q", msg)
+	}
+
+	opts.Strict = true
+	var reported []Diagnostic
+	opts.OnDiagnostic = func(d Diagnostic) { reported = append(reported, d) }
+	_, err = GenerateTypesWithOptions(opts)
+	var diagErr *DiagnosticsError
+	if !errors.As(err, &diagErr) || !strings.Contains(err.Error(), "1 conflicting definition(s)") {
+		t.Fatalf("expected conflict to fail strict mode, got %v", err)
+	}
+	if len(reported) != 1 || reported[0].Kind != DiagnosticConflict {
+		t.Fatalf("expected the conflict to reach OnDiagnostic before strict mode fails, got %v", reported)
+	}
+}
@@ -0,0 +1,235 @@
+package typegen
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+)
+
+// FieldCasing transforms the Go names of fields that have no name in the
+// struct tag property names are read from.
+type FieldCasing string
+
+const (
+	// CasingCamel turns UserID into userId.
+	CasingCamel FieldCasing = "camel"
+	// CasingSnake turns UserID into user_id.
+	CasingSnake FieldCasing = "snake"
+	// CasingKebab turns UserID into user-id.
+	CasingKebab FieldCasing = "kebab"
+)
+
+func validateFieldNaming(tag string, casing FieldCasing) error {
+	if strings.ContainsAny(tag, ` :"`) {
+		return fmt.Errorf("invalid field tag %q", tag)
+	}
+	switch casing {
+	case "", CasingCamel, CasingSnake, CasingKebab:
+		return nil
+	default:
+		return fmt.Errorf("unknown field casing %q", casing)
+	}
+}
+
+// hiddenPrefix starts the names of the properties of json:"-" fields, which
+// cannot clash with a Go name.
+const hiddenPrefix = "-"
+
+// addHiddenProperties adds the json:"-" fields of the structs in ts as
+// hidden properties, before the mutations run so that they are typed like
+// the others. renameProperties keeps those another field tag names.
+func addHiddenProperties(ts *guts.Typescript, golang *guts.GoParser, mapper *typeMapper, scan *sourceScan) error {
+	for _, st := range scan.types {
+		if !slices.ContainsFunc(st.properties, func(prop scannedProperty) bool { return prop.hidden }) {
+			continue
+		}
+		node, ok := ts.Node(st.key)
+		if !ok {
+			continue
+		}
+		iface, ok := node.(*bindings.Interface)
+		if !ok {
+			continue
+		}
+		pkg, ok := golang.Pkgs[st.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		obj := pkg.Types.Scope().Lookup(st.name)
+		if obj == nil {
+			continue
+		}
+		structType, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+
+		// Each hidden property goes after the property of the field
+		// before it, keeping the order of the struct.
+		at := 0
+		for _, prop := range st.properties {
+			if !prop.hidden {
+				if i := slices.IndexFunc(iface.Fields, func(field *bindings.PropertySignature) bool {
+					return field.Name == prop.name
+				}); i >= 0 {
+					at = i + 1
+				}
+				continue
+			}
+			var field *types.Var
+			for i := range structType.NumFields() {
+				if structType.Field(i).Name() == prop.goName {
+					field = structType.Field(i)
+				}
+			}
+			if field == nil {
+				continue
+			}
+			typ, err := mapper.expression(field.Type())
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", st.name, prop.goName, err)
+			}
+			iface.Fields = slices.Insert(iface.Fields, at, &bindings.PropertySignature{
+				Name:      prop.name,
+				Modifiers: []bindings.Modifier{},
+				Type:      typ,
+			})
+			at++
+		}
+	}
+	return nil
+}
+
+// renameProperties names the properties of struct declarations after the
+// struct tag tag instead of json, and applies casing to fields without a
+// name in it. A "-" name drops the property, and omitempty / omitzero in
+// that tag decide optionality under the policies of n, before the ts tag
+// overrides. json:"-" fields are kept only when tag names them.
+func renameProperties(decls []*declaration, scan *sourceScan, tag string, casing FieldCasing, n Nullability) {
+	if tag == "json" {
+		tag = ""
+	}
+
+	types := make(map[string]scannedType)
+	for _, st := range scan.types {
+		if len(st.properties) > 0 {
+			types[st.key] = st
+		}
+	}
+
+	for _, decl := range decls {
+		iface, ok := decl.node.(*bindings.Interface)
+		if !ok {
+			continue
+		}
+		st, ok := types[decl.key]
+		if !ok {
+			continue
+		}
+		byName := make(map[string]scannedProperty, len(st.properties))
+		for _, prop := range st.properties {
+			byName[prop.name] = prop
+		}
+
+		kept := iface.Fields[:0]
+		for _, field := range iface.Fields {
+			prop, ok := byName[field.Name]
+			if !ok {
+				kept = append(kept, field)
+				continue
+			}
+
+			if tag == "" {
+				if prop.hidden {
+					continue
+				}
+				if !prop.jsonTagged && casing != "" {
+					field.Name = applyCasing(prop.goName, casing)
+				}
+				kept = append(kept, field)
+				continue
+			}
+
+			value, tagged := prop.tag.Lookup(tag)
+			name, _, _ := strings.Cut(value, ",")
+			if (name == "-" && value == "-") || (prop.hidden && (!tagged || name == "-")) {
+				continue
+			}
+			omitempty := tagged && omitsEmpty(prop.tag, tag)
+			if omitempty != omitsEmpty(prop.tag, "json") {
+				if typ, ok := decl.omitempty[field.Name]; ok {
+					field.Type = cloneNode(typ).(bindings.ExpressionType)
+				}
+				field.QuestionToken = omitempty
+				n.applyField(field, prop, omitempty)
+				if override, ok := st.fields[field.Name]; ok {
+					applyFieldOverride(field, override)
+				}
+			}
+			if name != "" {
+				field.Name = name
+			} else {
+				field.Name = applyCasing(prop.goName, casing)
+			}
+			kept = append(kept, field)
+		}
+		if len(kept) < len(iface.Fields) {
+			iface.Fields = kept
+			decl.refs = referencedKeys(decl.node)
+		}
+	}
+}
+
+func applyCasing(name string, casing FieldCasing) string {
+	words := splitWords(name)
+	switch casing {
+	case CasingCamel:
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+			words[i] = word
+		}
+		return strings.Join(words, "")
+	case CasingSnake:
+		return strings.ToLower(strings.Join(words, "_"))
+	case CasingKebab:
+		return strings.ToLower(strings.Join(words, "-"))
+	default:
+		return name
+	}
+}
+
+// splitWords splits a Go identifier into words, keeping initialisms
+// together: HTTPServerID becomes HTTP, Server, ID.
+func splitWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		boundary := cur == '_' ||
+			((unicode.IsLower(prev) || unicode.IsDigit(prev)) && unicode.IsUpper(cur)) ||
+			(unicode.IsUpper(prev) && unicode.IsUpper(cur) && unicode.IsLower(next))
+		if boundary {
+			if word := strings.Trim(string(runes[start:i]), "_"); word != "" {
+				words = append(words, word)
+			}
+			start = i
+		}
+	}
+	if word := strings.Trim(string(runes[start:]), "_"); word != "" {
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,123 @@
+package typegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTargets_FieldNaming(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type UserReq struct {
+	UserID    int
+	Name      string `+"`json:\"name\" yaml:\"full_name,omitempty\"`"+`
+	Password  string `+"`yaml:\"-\"`"+`
+	HTTPProxy string `+"`json:\"proxy\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	base := Options{PkgDir: filepath.Join(root, "pkg")}
+	jsonPath := filepath.Join(root, "out", "json.d.ts")
+	yamlPath := filepath.Join(root, "out", "yaml.d.ts")
+	err := GenerateTargets(base, []Target{
+		{Name: "json", FieldCasing: CasingCamel, Output: OutputOptions{OutputPath: jsonPath}},
+		{Name: "yaml", FieldTag: "yaml", FieldCasing: CasingSnake, Output: OutputOptions{OutputPath: yamlPath}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTargets: %v", err)
+	}
+
+	jsonOut, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	for _, want := range []string{"readonly userId: number;", "readonly name: string;", "readonly password: string;", "readonly proxy: string;"} {
+		if !strings.Contains(string(jsonOut), want) {
+			t.Fatalf("expected %q in json output:\n%s", want, jsonOut)
+		}
+	}
+
+	yamlOut, err := os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatalf("read yaml: %v", err)
+	}
+	for _, want := range []string{"readonly user_id: number;", "readonly full_name?: string;", "readonly http_proxy: string;"} {
+		if !strings.Contains(string(yamlOut), want) {
+			t.Fatalf("expected %q in yaml output:\n%s", want, yamlOut)
+		}
+	}
+	if strings.Contains(string(yamlOut), "assword") {
+		t.Fatalf("expected yaml:\"-\" field to be dropped:\n%s", yamlOut)
+	}
+}
+
+func TestGenerateTypes_FieldTagOptionality(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/login.go", `package foo
+
+type Login struct {
+	User   string  `+"`json:\"user\" form:\"user\"`"+`
+	Token  string  `+"`json:\"-\" form:\"token\"`"+`
+	Secret string  `+"`json:\"-\"`"+`
+	Note   string  `+"`json:\"note,omitempty\"`"+`
+	Code   string  `+"`json:\"code\" form:\"code,omitempty\" ts:\"optional=false\"`"+`
+	Next   *string `+"`json:\"next\" form:\"next\" ts:\"optional\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), FieldTag: "form"}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"readonly user: string;\n    readonly token: string;",
+		"readonly Note: string;",
+		"readonly code: string;",
+		"readonly next?: string | null;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Secret") {
+		t.Fatalf("expected json:\"-\" field without a form name to be dropped:\n%s", output)
+	}
+
+	opts.FieldTag = ""
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (json): %v", err)
+	}
+	if strings.Contains(output, "oken") || !strings.Contains(output, "readonly note?: string;") {
+		t.Fatalf("expected json names and optionality:\n%s", output)
+	}
+}
+
+func TestApplyCasing(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		casing FieldCasing
+		want   string
+	}{
+		{"UserID", CasingCamel, "userId"},
+		{"HTTPServerID", CasingSnake, "http_server_id"},
+		{"Address2Line", CasingKebab, "address2-line"},
+		{"Name", "", "Name"},
+	} {
+		if got := applyCasing(tc.name, tc.casing); got != tc.want {
+			t.Fatalf("applyCasing(%q, %q) = %q, want %q", tc.name, tc.casing, got, tc.want)
+		}
+	}
+}
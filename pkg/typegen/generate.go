@@ -3,20 +3,20 @@ package typegen
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
 	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/coder/guts"
-	"github.com/coder/guts/config"
+	"github.com/coder/guts/bindings"
 )
 
 type Options struct {
@@ -31,11 +31,76 @@ type Options struct {
 	IncludeType string
 	// StripPrefix removes package prefixes from generated identifiers (e.g. foo__bar_Baz -> Baz).
 	StripPrefix bool
-	// DisableRename skips the rename scan (TypeNameMapper is ignored) to avoid collisions.
+	// Collisions selects how StripPrefix handles declarations of different
+	// packages that strip to the same name. When empty, CollisionError is used.
+	Collisions CollisionStrategy
+	// ResolveCollision, when set, picks the name of every declaration in a
+	// collision and takes precedence over Collisions.
+	ResolveCollision func(NameCollision) string
+	// DisableRename skips the rename scan (TypeNameMapper and Namer are ignored) to avoid collisions.
 	DisableRename bool
 	// TypeNameMapper maps Go struct names to custom TypeScript names.
-	// It is ignored when DisableRename is true.
+	// It is ignored when DisableRename is true or Namer is set.
 	TypeNameMapper func(typeName string, moduleName string) string
+	// Namer picks the TypeScript name of every exported declaration kind,
+	// given its package, file, doc comment and struct tags. It replaces
+	// TypeNameMapper and is ignored when DisableRename is true. See
+	// PackagePrefixNamer, LastSegmentNamer, SuffixNamer and ChainNamers.
+	Namer Namer
+	// FieldTag is the struct tag property names are read from, e.g. "yaml",
+	// "msgpack" or "form". When empty, the json tag is used.
+	FieldTag string
+	// FieldCasing transforms the Go names of fields without a FieldTag name.
+	// When empty, Go names are kept.
+	FieldCasing FieldCasing
+	// TypeMappings maps fully qualified Go types to TypeScript type
+	// expressions, on top of the standard mappings (time.Time, uuid.UUID,
+	// sql.Null*). Keys look like "github.com/shopspring/decimal.Decimal";
+	// generic types name their parameters, e.g. "database/sql.Null[T]": "T | null".
+	// Mapped types of the scanned packages are no longer declared.
+	TypeMappings map[string]string
+	// Unions emits interfaces as discriminated unions of the structs
+	// implementing them, like a //typegen:union <field> doc comment line.
+	// Keys are "<import path>.<Name>", values the discriminator field.
+	Unions map[string]string
+	// ClientImport is the module specifier the fetch client (FormatClient)
+	// imports the generated types from. When empty, "./index" is used.
+	ClientImport string
+	// Services emits interfaces as RPC contracts with async methods, like a
+	// //typegen:rpc doc comment line. Entries are "<import path>.<Name>".
+	Services []string
+	// Brands lists packages, relative to PkgDir, whose defined scalar types
+	// (type UserID string) are emitted as branded types: "." is PkgDir,
+	// "user" a single package and "user/..." a package and those below it.
+	// A //typegen:brand or //typegen:nobrand doc comment line overrides it
+	// per type.
+	Brands []string
+	// BrandHelpers follows every branded type with a constructor function
+	// of the same name. The output must then be a .ts file, not .d.ts.
+	BrandHelpers bool
+	// Int64 selects the TypeScript type of int64 and uint64 values. When
+	// empty, they stay number. Fields tagged json:",string" are strings
+	// regardless.
+	Int64 Int64Mode
+	// Mutations names the guts mutations applied to the generated
+	// TypeScript, in order. When empty, DefaultMutations is used; a list of
+	// "-name" entries only removes those from it. Custom mutations are added
+	// with RegisterMutation.
+	Mutations []string
+	// Nullability chooses how pointers, omitempty fields, slices and maps
+	// are typed: T | null, T?, T | undefined or T. Empty categories keep the
+	// defaults.
+	Nullability Nullability
+	// JSONv2 generates what encoding/json/v2 marshals: inline and unknown
+	// fields are flattened, format options change property types and
+	// omitempty no longer makes numbers and booleans optional.
+	JSONv2 bool
+	// Strict fails generation with a *DiagnosticsError when any package
+	// failed to load, instead of leaving its types out.
+	Strict bool
+	// OnDiagnostic, when set, is called for every package that was skipped
+	// or loaded with errors.
+	OnDiagnostic func(Diagnostic)
 }
 
 type OutputOptions struct {
@@ -43,16 +108,81 @@ type OutputOptions struct {
 	OutputPath string
 	// Stdout writes to stdout instead of a file when true.
 	Stdout bool
+	// Format selects what is written. When empty, FormatTypeScript is used.
+	Format Format
 }
 
-const defaultOutputFile = "index.d.ts"
+// Format selects the kind of file produced by GenerateTypesToOutput.
+type Format string
+
+const (
+	// FormatTypeScript emits TypeScript declarations (index.d.ts).
+	FormatTypeScript Format = "ts"
+	// FormatZod emits Zod schemas plus z.infer type aliases (schemas.ts).
+	FormatZod Format = "zod"
+	// FormatJSONSchema emits a JSON Schema draft 2020-12 document (schema.json).
+	FormatJSONSchema Format = "jsonschema"
+	// FormatOpenAPI emits an OpenAPI 3.1 components document in YAML (openapi.yaml).
+	FormatOpenAPI Format = "openapi"
+	// FormatOpenAPIJSON emits the same OpenAPI document as JSON (openapi.json).
+	FormatOpenAPIJSON Format = "openapi-json"
+	// FormatClient emits a fetch client for the handlers carrying a
+	// //typegen:route directive (client.ts).
+	FormatClient Format = "client"
+	// FormatRoutes emits a manifest of the patterns registered on net/http
+	// ServeMuxes (routes.ts).
+	FormatRoutes Format = "routes"
+	// FormatConstants emits the values of enums and of constants carrying a
+	// //typegen:const directive, as a companion .ts module (constants.ts).
+	FormatConstants Format = "constants"
+)
+
+const (
+	defaultOutputFile            = "index.d.ts"
+	defaultZodOutputFile         = "schemas.ts"
+	defaultJSONSchemaOutputFile  = "schema.json"
+	defaultOpenAPIOutputFile     = "openapi.yaml"
+	defaultOpenAPIJSONOutputFile = "openapi.json"
+	defaultClientOutputFile      = "client.ts"
+	defaultRoutesOutputFile      = "routes.ts"
+	defaultConstantsOutputFile   = "constants.ts"
+)
 
 func DefaultOutputPath() string {
+	return defaultOutputPathFor(defaultOutputFile)
+}
+
+func defaultOutputPathFor(file string) string {
 	exePath, err := os.Executable()
 	if err != nil {
-		return defaultOutputFile
+		return file
+	}
+	return filepath.Join(filepath.Dir(exePath), file)
+}
+
+// resolveOutputPath returns the cleaned output path, defaulting by format.
+func resolveOutputPath(output OutputOptions) string {
+	if output.OutputPath != "" {
+		return filepath.Clean(output.OutputPath)
+	}
+	switch output.Format {
+	case FormatZod:
+		return defaultOutputPathFor(defaultZodOutputFile)
+	case FormatJSONSchema:
+		return defaultOutputPathFor(defaultJSONSchemaOutputFile)
+	case FormatOpenAPI:
+		return defaultOutputPathFor(defaultOpenAPIOutputFile)
+	case FormatOpenAPIJSON:
+		return defaultOutputPathFor(defaultOpenAPIJSONOutputFile)
+	case FormatClient:
+		return defaultOutputPathFor(defaultClientOutputFile)
+	case FormatRoutes:
+		return defaultOutputPathFor(defaultRoutesOutputFile)
+	case FormatConstants:
+		return defaultOutputPathFor(defaultConstantsOutputFile)
+	default:
+		return DefaultOutputPath()
 	}
-	return filepath.Join(filepath.Dir(exePath), defaultOutputFile)
 }
 
 // GenerateTypes generates TypeScript types from Go structs
@@ -64,126 +194,415 @@ func GenerateTypes(pkgPath string) (string, error) {
 
 // GenerateTypesWithOptions generates TypeScript types with custom configuration.
 func GenerateTypesWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return gen.output, nil
+}
+
+// GenerateTypesWithDiagnostics is GenerateTypesWithOptions that also returns
+// the packages that were skipped or loaded with errors and the conflicting
+// definitions that were dropped.
+func GenerateTypesWithDiagnostics(opts Options) (string, Diagnostics, error) {
+	tree, err := parse(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	gen, err := tree.generate(opts)
+	if err != nil {
+		return "", tree.diagnostics, err
+	}
+	return gen.output, append(slices.Clip(tree.diagnostics), gen.diagnostics...), nil
+}
+
+// generation is the result of the shared pipeline. The TypeScript output is
+// the primary product; the other formats are rendered from decls.
+type generation struct {
+	// pkgImportPath is the resolved import path of Options.PkgDir.
+	pkgImportPath string
+	output        string
+	// decls are the declarations present in output, in output order, with
+	// identifiers already renamed.
+	decls  []*declaration
+	byName map[string]*declaration
+	// diagnostics are the conflicting definitions dropped by this
+	// generation.
+	diagnostics Diagnostics
+	// rename maps declaration keys to their names in output.
+	rename func(ref string) string
+	// routes and clientImport are rendered by FormatClient.
+	routes       func() ([]route, error)
+	clientImport string
+	// muxRoutes are rendered by FormatRoutes.
+	muxRoutes func() ([]muxRoute, error)
+	// enums and constants are rendered by FormatConstants.
+	enums     map[string][]constValue
+	constants func() ([]constValue, error)
+}
+
+func generate(opts Options) (*generation, error) {
+	tree, err := parse(opts)
+	if err != nil {
+		return nil, err
+	}
+	return tree.generate(opts)
+}
+
+// parsedTree holds the packages under PkgDir loaded, type-checked and
+// converted once. Any number of generations can be derived from it, each
+// with its own whitelist and rename settings.
+type parsedTree struct {
+	pkgDir        string
+	pkgImportPath string
+	packages      []packageInfo
+	scan          *sourceScan
+	diagnostics   Diagnostics
+	// header is the generated-code comment guts starts its output with.
+	header string
+	// decls are the unfiltered declarations with their original keys. They
+	// are cloned before any per-generation pass mutates them.
+	decls []*declaration
+	// routes resolves the handlers of the fetch client once, see
+	// buildRoutes. Only FormatClient calls it, so a broken route directive
+	// does not fail the other formats.
+	routes func() ([]route, error)
+	// muxRoutes resolves the ServeMux registrations once, see
+	// scanMuxRoutes. Only FormatRoutes calls it.
+	muxRoutes func() ([]muxRoute, error)
+	// enums are the values of enums by declaration key, see
+	// collectEnumValues. constants resolves the constants carrying the
+	// const directive once, see collectConstants. Only FormatConstants
+	// calls it, so a misplaced directive does not fail the other formats.
+	enums     map[string][]constValue
+	constants func() ([]constValue, error)
+}
+
+// parse runs the expensive part of the pipeline shared by every target. The
+// whitelist and rename settings of opts, which Target replaces, are ignored.
+func parse(opts Options) (*parsedTree, error) {
 	if opts.PkgDir == "" {
-		return "", fmt.Errorf("pkg-dir is required")
+		return nil, fmt.Errorf("pkg-dir is required")
 	}
 
 	pkgDir, err := resolvePkgDir(opts.PkgDir)
 	if err != nil {
-		return "", fmt.Errorf("resolve pkg dir: %w", err)
+		return nil, fmt.Errorf("resolve pkg dir: %w", err)
 	}
 
 	pkgImportPath, err := resolvePkgPath(pkgDir, opts.PkgPath)
 	if err != nil {
-		return "", fmt.Errorf("resolve pkg import path: %w", err)
+		return nil, fmt.Errorf("resolve pkg import path: %w", err)
 	}
 
-	packages, err := findPackages(pkgDir, pkgImportPath)
+	mappings, err := parseTypeMappings(opts.TypeMappings)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Nullability.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateInt64Mode(opts.Int64); err != nil {
+		return nil, err
+	}
+	if err := validateBrandPackages(opts.Brands); err != nil {
+		return nil, err
+	}
+	muts, err := resolveMutations(opts.Mutations)
 	if err != nil {
-		return "", fmt.Errorf("find packages: %w", err)
+		return nil, err
 	}
 
-	interfaceTypes, err := collectInterfaceTypeNames(pkgDir, pkgImportPath)
+	packages, err := findPackages(pkgDir, pkgImportPath)
 	if err != nil {
-		return "", fmt.Errorf("collect interface types: %w", err)
+		return nil, fmt.Errorf("find packages: %w", err)
 	}
 
-	var renameMap map[string]string
-	if !opts.DisableRename {
-		renameMap, err = collectStructRenameMap(pkgDir, pkgImportPath, opts.TypeNameMapper)
-		if err != nil {
-			return "", fmt.Errorf("collect struct rename map: %w", err)
-		}
+	scan, err := scanSources(pkgDir, pkgImportPath)
+	if err != nil {
+		return nil, fmt.Errorf("scan sources: %w", err)
 	}
 
 	// 使用单一 parser 处理所有包，确保跨包引用正确解析
 	golang, err := guts.NewGolangParser()
 	if err != nil {
-		return "", fmt.Errorf("create parser: %w", err)
+		return nil, fmt.Errorf("create parser: %w", err)
 	}
 
 	golang.PreserveComments()
-	golang.IncludeCustomDeclaration(config.StandardMappings())
 
+	var diagnostics Diagnostics
 	for _, pkg := range packages {
 		prefix := prefixForImportPath(pkgImportPath, pkg.importPath)
 		if err := golang.IncludeGenerateWithPrefix(pkg.importPath, prefix); err != nil {
-			// Skip packages that fail (may have no Go files)
+			diagnostics = append(diagnostics, packageDiagnostic(pkg, []error{err}))
 			continue
 		}
+		// guts keeps packages with errors and only logs them, so look for
+		// them here before their types silently go missing.
+		if loaded, ok := golang.Pkgs[pkg.importPath]; ok && len(loaded.Errors) > 0 {
+			errs := make([]error, 0, len(loaded.Errors))
+			for _, err := range loaded.Errors {
+				errs = append(errs, err)
+			}
+			diagnostics = append(diagnostics, packageDiagnostic(pkg, errs))
+		}
+	}
+
+	mapper, err := installTypeMappings(golang, mappings, opts.Int64)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.OnDiagnostic != nil {
+		for _, diag := range diagnostics {
+			opts.OnDiagnostic(diag)
+		}
+	}
+	if failed := diagnostics.Failed(); opts.Strict && len(failed) > 0 {
+		return nil, &DiagnosticsError{Diagnostics: failed}
 	}
 
 	ts, err := golang.ToTypescript()
 	if err != nil {
-		return "", fmt.Errorf("convert to typescript: %w", err)
+		return nil, fmt.Errorf("convert to typescript: %w", err)
 	}
 
-	ts.ApplyMutations(
-		config.ExportTypes,
-		config.EnumAsTypes,
-		config.ReadOnly,
-		config.NullUnionSlices,
-		config.NotNullMaps,
-		config.BiomeLintIgnoreAnyTypeParameters,
-	)
+	if err := addHiddenProperties(ts, golang, mapper, scan); err != nil {
+		return nil, err
+	}
+	ts.ApplyMutations(muts...)
 
-	output, err := ts.Serialize()
+	decls := collectDeclarations(ts)
+	// Serializing no declarations leaves the header guts writes.
+	header, err := ts.SerializeInOrder(func(map[string]bindings.Node) []bindings.Node { return nil })
 	if err != nil {
-		return "", fmt.Errorf("serialize: %w", err)
+		return nil, fmt.Errorf("serialize header: %w", err)
+	}
+	stripDirectiveComments(decls)
+	decls = excludeDeclarations(decls, mappedDeclarationKeys(pkgImportPath, packages, mappings))
+	applyInt64(decls, scan, opts.Int64)
+	applyBrands(decls, scan, opts.Brands)
+	applyNullability(decls, scan, opts.Nullability)
+	if opts.JSONv2 {
+		applyJSONv2(decls, scan)
+	}
+	applyFieldOverrides(decls, scan)
+	decls, err = buildUnions(golang, decls, scan, packages, pkgImportPath, opts.Unions)
+	if err != nil {
+		return nil, err
 	}
+	decls, err = buildServices(golang, mapper, decls, scan, opts.Services)
+	if err != nil {
+		return nil, err
+	}
+	// Services convert their signatures with mapper, which may use the
+	// Int64String brand too.
+	decls = declareInt64Brand(decls)
+	routes := sync.OnceValues(func() ([]route, error) {
+		return buildRoutes(golang, mapper, scan, pkgImportPath)
+	})
+	muxRoutes := sync.OnceValues(func() ([]muxRoute, error) {
+		routes, err := scanMuxRoutes(golang, pkgDir, packages)
+		if err != nil {
+			return nil, fmt.Errorf("scan routes: %w", err)
+		}
+		return routes, nil
+	})
+	constants := sync.OnceValues(func() ([]constValue, error) {
+		return collectConstants(golang, scan)
+	})
+
+	return &parsedTree{
+		pkgDir:        pkgDir,
+		pkgImportPath: pkgImportPath,
+		packages:      packages,
+		scan:          scan,
+		diagnostics:   diagnostics,
+		header:        header,
+		decls:         decls,
+		routes:        routes,
+		muxRoutes:     muxRoutes,
+		enums:         collectEnumValues(golang, scan),
+		constants:     constants,
+	}, nil
+}
 
-	var prefixes []string
-	if opts.StripPrefix || (opts.IncludeType != "" && len(renameMap) == 0) {
-		prefixes = collectPrefixes(pkgImportPath, packages)
+// generate applies the whitelist and rename settings of opts to a copy of
+// the parsed declarations and serializes the result. PkgDir and PkgPath of
+// opts are ignored.
+func (tree *parsedTree) generate(opts Options) (*generation, error) {
+	var err error
+	var renameMap map[string]string
+	if !opts.DisableRename {
+		// Without a mapper, clashes are the same problem StripPrefix
+		// resolves, so they are left to the collision strategy.
+		dropCollisions := opts.StripPrefix && opts.TypeNameMapper == nil && opts.Namer == nil
+		renameMap, err = collectRenameMap(tree.scan, opts, dropCollisions)
+		if err != nil {
+			return nil, fmt.Errorf("collect rename map: %w", err)
+		}
 	}
 
-	if opts.IncludePattern != "" || opts.IncludeType != "" {
-		var fileRegexp *regexp.Regexp
-		var typeRegexp *regexp.Regexp
-		var err error
-		if opts.IncludePattern != "" {
-			fileRegexp, err = regexp.Compile(opts.IncludePattern)
-			if err != nil {
-				return "", fmt.Errorf("compile include pattern: %w", err)
-			}
+	if err := validateCollisionStrategy(opts.Collisions); err != nil {
+		return nil, err
+	}
+	if err := validateFieldNaming(opts.FieldTag, opts.FieldCasing); err != nil {
+		return nil, err
+	}
+
+	var fileRegexp *regexp.Regexp
+	var typeRegexp *regexp.Regexp
+	if opts.IncludePattern != "" {
+		fileRegexp, err = regexp.Compile(opts.IncludePattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile include pattern: %w", err)
 		}
-		if opts.IncludeType != "" {
-			typeRegexp, err = regexp.Compile(opts.IncludeType)
-			if err != nil {
-				return "", fmt.Errorf("compile include type pattern: %w", err)
-			}
+	}
+	if opts.IncludeType != "" {
+		typeRegexp, err = regexp.Compile(opts.IncludeType)
+		if err != nil {
+			return nil, fmt.Errorf("compile include type pattern: %w", err)
 		}
-		output = filterByWhitelist(output, fileRegexp, typeRegexp, renameMap, prefixes)
 	}
 
-	output = filterInterfaceTypes(output, interfaceTypes)
-	if len(renameMap) > 0 {
-		output = renameIdentifiers(output, renameMap)
+	prefixes := collectPrefixes(tree.pkgImportPath, tree.packages)
+	rename := func(ref string) string {
+		name := ref
+		if next, ok := renameMap[ref]; ok && next != "" {
+			name = next
+		}
+		if opts.StripPrefix {
+			name = stripPrefixToken(name, prefixes)
+		}
+		return name
 	}
+
+	decls := cloneDeclarations(tree.decls)
+	renameProperties(decls, tree.scan, opts.FieldTag, opts.FieldCasing, opts.Nullability)
+	decls = selectDeclarations(decls, fileRegexp, typeRegexp, func(key string) string {
+		// Match against the unprefixed name even without StripPrefix.
+		return stripPrefixToken(rename(key), prefixes)
+	})
+	decls = excludeDeclarations(decls, tree.scan.interfaces)
 	if opts.StripPrefix {
-		output = stripPrefixes(output, prefixes)
+		resolved, err := tree.resolveCollisions(decls, rename, opts)
+		if err != nil {
+			return nil, err
+		}
+		stripped := rename
+		rename = func(ref string) string {
+			if name, ok := resolved[ref]; ok {
+				return name
+			}
+			return stripped(ref)
+		}
 	}
-	output = deduplicateTypes(output)
+	renameDeclarations(decls, rename)
+	decls, conflicts := deduplicateDeclarations(decls)
 
-	return output, nil
+	var diagnostics Diagnostics
+	for _, conflict := range conflicts {
+		diagnostics = append(diagnostics, tree.conflictDiagnostic(conflict))
+	}
+	if opts.OnDiagnostic != nil {
+		for _, diag := range diagnostics {
+			opts.OnDiagnostic(diag)
+		}
+	}
+	if opts.Strict && len(diagnostics) > 0 {
+		return nil, &DiagnosticsError{Diagnostics: diagnostics}
+	}
+
+	output, err := serializeDeclarations(tree.header, decls, opts.BrandHelpers)
+	if err != nil {
+		return nil, fmt.Errorf("serialize: %w", err)
+	}
+
+	byName := make(map[string]*declaration, len(decls))
+	for _, decl := range decls {
+		byName[decl.name] = decl
+	}
+
+	return &generation{
+		pkgImportPath: tree.pkgImportPath,
+		output:        output,
+		decls:         decls,
+		byName:        byName,
+		diagnostics:   diagnostics,
+		rename:        rename,
+		routes:        tree.routes,
+		clientImport:  cmp.Or(opts.ClientImport, defaultClientImport),
+		muxRoutes:     tree.muxRoutes,
+		enums:         tree.enums,
+		constants:     tree.constants,
+	}, nil
 }
 
-func GenerateTypesToOutput(opts Options, output OutputOptions) error {
-	content, err := GenerateTypesWithOptions(opts)
+// generateFormat runs the pipeline and renders the requested format.
+func generateFormat(opts Options, format Format) (string, error) {
+	if err := validateFormat(format); err != nil {
+		return "", err
+	}
+	gen, err := generate(opts)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return renderFormat(gen, format)
+}
+
+func validateFormat(format Format) error {
+	switch format {
+	case "", FormatTypeScript, FormatZod, FormatJSONSchema, FormatOpenAPI, FormatOpenAPIJSON, FormatClient, FormatRoutes, FormatConstants:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
 
-	if output.OutputPath == "" {
-		output.OutputPath = DefaultOutputPath()
+// renderFormat renders a generation in the requested format.
+func renderFormat(gen *generation, format Format) (string, error) {
+	switch format {
+	case "", FormatTypeScript:
+		return gen.output, nil
+	case FormatZod:
+		return renderZod(gen)
+	case FormatJSONSchema:
+		return renderJSONSchema(gen)
+	case FormatOpenAPI:
+		return renderOpenAPI(gen)
+	case FormatOpenAPIJSON:
+		return renderOpenAPIJSON(gen)
+	case FormatClient:
+		return renderClient(gen)
+	case FormatRoutes:
+		return renderRoutes(gen)
+	case FormatConstants:
+		return renderConstants(gen)
+	default:
+		return "", fmt.Errorf("unknown output format %q", format)
 	}
+}
 
+func GenerateTypesToOutput(opts Options, output OutputOptions) error {
+	if err := checkBrandHelpers(opts, output); err != nil {
+		return err
+	}
+	content, err := generateFormat(opts, output.Format)
+	if err != nil {
+		return err
+	}
+	return writeOutput(content, output)
+}
+
+// writeOutput writes content to stdout or to the resolved output path.
+func writeOutput(content string, output OutputOptions) error {
 	if output.Stdout || output.OutputPath == "-" {
 		_, err := os.Stdout.WriteString(content)
 		return err
 	}
 
-	outPath := filepath.Clean(output.OutputPath)
+	outPath := resolveOutputPath(output)
 	if outPath == "" {
 		return fmt.Errorf("output path is required unless stdout is set")
 	}
@@ -201,6 +620,8 @@ func GenerateTypesToOutput(opts Options, output OutputOptions) error {
 
 type packageInfo struct {
 	importPath string
+	// dir is the absolute directory holding the package's Go files.
+	dir string
 }
 
 // findPackages discovers all packages under pkgDir
@@ -254,7 +675,7 @@ func findPackages(pkgDir, pkgImportPath string) ([]packageInfo, error) {
 
 		if _, ok := seen[importPath]; !ok {
 			seen[importPath] = struct{}{}
-			packages = append(packages, packageInfo{importPath: importPath})
+			packages = append(packages, packageInfo{importPath: importPath, dir: dir})
 		}
 
 		return nil
@@ -271,92 +692,12 @@ func findPackages(pkgDir, pkgImportPath string) ([]packageInfo, error) {
 	return packages, nil
 }
 
-func collectInterfaceTypeNames(pkgDir, pkgImportPath string) (map[string]struct{}, error) {
-	interfaces := make(map[string]struct{})
-	fset := token.NewFileSet()
-
-	err := filepath.WalkDir(pkgDir, func(dir string, entry fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if !entry.IsDir() {
-			return nil
-		}
-
-		name := entry.Name()
-		if dir != pkgDir && (name == "typegen" || strings.HasPrefix(name, ".")) {
-			return filepath.SkipDir
-		}
-
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			return err
-		}
-
-		var goFiles []string
-		for _, file := range entries {
-			if file.IsDir() {
-				continue
-			}
-			fileName := file.Name()
-			if strings.HasSuffix(fileName, ".go") && !strings.HasSuffix(fileName, "_test.go") {
-				goFiles = append(goFiles, filepath.Join(dir, fileName))
-			}
-		}
-
-		if len(goFiles) == 0 {
-			return nil
-		}
-
-		rel, err := filepath.Rel(pkgDir, dir)
-		if err != nil {
-			return err
-		}
-
-		importPath := pkgImportPath
-		if rel != "." {
-			importPath = path.Join(pkgImportPath, filepath.ToSlash(rel))
-		}
-		prefix := prefixForImportPath(pkgImportPath, importPath)
-
-		for _, filePath := range goFiles {
-			parsed, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution)
-			if err != nil {
-				return fmt.Errorf("parse file %s: %w", filePath, err)
-			}
-
-			for _, decl := range parsed.Decls {
-				genDecl, ok := decl.(*ast.GenDecl)
-				if !ok || genDecl.Tok != token.TYPE {
-					continue
-				}
-				for _, spec := range genDecl.Specs {
-					typeSpec, ok := spec.(*ast.TypeSpec)
-					if !ok {
-						continue
-					}
-					if _, ok := typeSpec.Type.(*ast.InterfaceType); !ok {
-						continue
-					}
-					name := typeSpec.Name.Name
-					if name == "" || !ast.IsExported(name) {
-						continue
-					}
-					interfaces[prefix+name] = struct{}{}
-				}
-			}
-		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("walk pkg dir: %w", err)
-	}
-
-	return interfaces, nil
-}
-
-func collectStructRenameMap(pkgDir, pkgImportPath string, mapper func(typeName, moduleName string) string) (map[string]string, error) {
+// collectRenameMap maps the keys of scanned types to the names chosen by
+// opts.Namer, or of structs to the names chosen by opts.TypeNameMapper.
+// Types whose new names clash are an error, unless dropCollisions is set, in
+// which case they keep their keys.
+func collectRenameMap(scan *sourceScan, opts Options, dropCollisions bool) (map[string]string, error) {
+	mapper := opts.TypeNameMapper
 	if mapper == nil {
 		mapper = func(typeName, moduleName string) string {
 			return typeName
@@ -365,406 +706,40 @@ func collectStructRenameMap(pkgDir, pkgImportPath string, mapper func(typeName,
 
 	renames := make(map[string]string)
 	seenNew := make(map[string]string)
-	fset := token.NewFileSet()
-
-	err := filepath.WalkDir(pkgDir, func(dir string, entry fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if !entry.IsDir() {
-			return nil
-		}
-
-		name := entry.Name()
-		if dir != pkgDir && (name == "typegen" || strings.HasPrefix(name, ".")) {
-			return filepath.SkipDir
-		}
-
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			return err
-		}
-
-		var goFiles []string
-		for _, file := range entries {
-			if file.IsDir() {
-				continue
-			}
-			fileName := file.Name()
-			if strings.HasSuffix(fileName, ".go") && !strings.HasSuffix(fileName, "_test.go") {
-				goFiles = append(goFiles, filepath.Join(dir, fileName))
-			}
-		}
-		if len(goFiles) == 0 {
-			return nil
-		}
-
-		rel, err := filepath.Rel(pkgDir, dir)
-		if err != nil {
-			return err
-		}
-
-		importPath := pkgImportPath
-		moduleName := path.Base(pkgImportPath)
-		if rel != "." {
-			importPath = path.Join(pkgImportPath, filepath.ToSlash(rel))
-			parts := strings.Split(filepath.ToSlash(rel), "/")
-			if len(parts) > 0 {
-				moduleName = parts[0]
-			}
-		}
-		prefix := prefixForImportPath(pkgImportPath, importPath)
-
-		for _, filePath := range goFiles {
-			parsed, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution)
-			if err != nil {
-				return fmt.Errorf("parse file %s: %w", filePath, err)
-			}
-
-			for _, decl := range parsed.Decls {
-				genDecl, ok := decl.(*ast.GenDecl)
-				if !ok || genDecl.Tok != token.TYPE {
-					continue
-				}
-				for _, spec := range genDecl.Specs {
-					typeSpec, ok := spec.(*ast.TypeSpec)
-					if !ok {
-						continue
-					}
-					if _, ok := typeSpec.Type.(*ast.StructType); !ok {
-						continue
-					}
-					name := typeSpec.Name.Name
-					if name == "" || !ast.IsExported(name) {
-						continue
-					}
-					newName := mapper(name, moduleName)
-					if newName == "" {
-						continue
-					}
-					oldName := prefix + name
-					if newName == oldName {
-						continue
-					}
-					if existing, ok := seenNew[newName]; ok && existing != oldName {
-						return fmt.Errorf("type name mapper collision: %s and %s -> %s", existing, oldName, newName)
-					}
-					seenNew[newName] = oldName
-					renames[oldName] = newName
-				}
-			}
-		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("walk pkg dir: %w", err)
-	}
-
-	return renames, nil
-}
-
-func filterByWhitelist(content string, fileRegexp, typeRegexp *regexp.Regexp, rename map[string]string, prefixes []string) string {
-	if fileRegexp == nil && typeRegexp == nil {
-		return content
-	}
-
-	type tsBlock struct {
-		source string
-		lines  []string
-		name   string
-	}
-
-	lines := strings.Split(content, "\n")
-	var header []string
-	var blocks []tsBlock
-	var current *tsBlock
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "// From ") {
-			if current != nil {
-				blocks = append(blocks, *current)
-			}
-			current = &tsBlock{
-				source: strings.TrimPrefix(line, "// From "),
-				lines:  []string{line},
-			}
-			continue
-		}
-
-		if current == nil {
-			header = append(header, line)
-			continue
-		}
-
-		current.lines = append(current.lines, line)
-	}
-
-	if current != nil {
-		blocks = append(blocks, *current)
-	}
-
-	exportNames := make(map[string]struct{})
-	for i := range blocks {
-		for _, line := range blocks[i].lines {
-			name := extractExportName(line)
-			if name != "" {
-				blocks[i].name = name
-				exportNames[name] = struct{}{}
-				break
-			}
-		}
-	}
-
-	tokenRe := regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\b`)
-	refsByName := make(map[string]map[string]struct{})
-	for _, block := range blocks {
-		if block.name == "" {
-			continue
-		}
-		refs := make(map[string]struct{})
-		for _, line := range block.lines {
-			trimmed := strings.TrimSpace(line)
-			if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*") {
-				continue
-			}
-			for _, token := range tokenRe.FindAllString(line, -1) {
-				if token == block.name {
-					continue
-				}
-				if _, ok := exportNames[token]; ok {
-					refs[token] = struct{}{}
-				}
-			}
-		}
-		refsByName[block.name] = refs
-	}
-
-	selected := make(map[string]struct{})
-	queue := make([]string, 0)
-	for _, block := range blocks {
-		if block.name == "" {
-			continue
-		}
-		if matchesWhitelist(block.source, block.name, fileRegexp, typeRegexp, rename, prefixes) {
-			if _, ok := selected[block.name]; !ok {
-				selected[block.name] = struct{}{}
-				queue = append(queue, block.name)
-			}
-		}
-	}
-
-	for len(queue) > 0 {
-		name := queue[0]
-		queue = queue[1:]
-		for ref := range refsByName[name] {
-			if _, ok := selected[ref]; ok {
-				continue
-			}
-			selected[ref] = struct{}{}
-			queue = append(queue, ref)
-		}
-	}
-
-	result := append([]string{}, header...)
-	for _, block := range blocks {
-		if block.name == "" {
-			continue
-		}
-		if _, ok := selected[block.name]; ok {
-			result = append(result, block.lines...)
-		}
-	}
-
-	return strings.Join(result, "\n")
-}
-
-func matchesWhitelist(source, name string, fileRegexp, typeRegexp *regexp.Regexp, rename map[string]string, prefixes []string) bool {
-	if fileRegexp != nil && !fileRegexp.MatchString(source) {
-		return false
-	}
-	if typeRegexp == nil {
-		return true
-	}
-	if name == "" {
-		return false
-	}
-	mapped := name
-	if rename != nil {
-		if next, ok := rename[name]; ok && next != "" {
-			mapped = next
-		}
-	} else if len(prefixes) > 0 {
-		mapped = stripPrefixToken(mapped, prefixes)
-	}
-	return typeRegexp.MatchString(mapped)
-}
-
-func filterInterfaceTypes(content string, excluded map[string]struct{}) string {
-	if len(excluded) == 0 {
-		return content
-	}
-
-	lines := strings.Split(content, "\n")
-	var result []string
-	var pending []string
-	var current []string
-	var currentName string
-	seenType := false
-
-	flushCurrent := func() {
-		if current == nil {
-			return
-		}
-		if _, skip := excluded[currentName]; !skip {
-			result = append(result, current...)
-		}
-		current = nil
-		currentName = ""
-	}
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "export interface ") || strings.HasPrefix(line, "export type ") {
-			if current != nil {
-				flushCurrent()
-			} else if !seenType && len(pending) > 0 {
-				result = append(result, pending...)
-				pending = nil
-			}
-
-			seenType = true
-			currentName = extractTypeName(line)
-			current = append(current, pending...)
-			current = append(current, line)
-			pending = nil
-			continue
-		}
-
-		if current != nil {
-			current = append(current, line)
-			continue
-		}
-
-		if seenType {
-			pending = append(pending, line)
-		} else {
-			result = append(result, line)
-		}
-	}
-
-	if current != nil {
-		flushCurrent()
-	} else if !seenType && len(pending) > 0 {
-		result = append(result, pending...)
-	}
-
-	return strings.Join(result, "\n")
-}
-
-func renameIdentifiers(content string, rename map[string]string) string {
-	if len(rename) == 0 {
-		return content
-	}
-
-	re := regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\b`)
-	return re.ReplaceAllStringFunc(content, func(token string) string {
-		if replacement, ok := rename[token]; ok {
-			return replacement
-		}
-		return token
-	})
-}
-
-// deduplicateTypes removes duplicate type definitions (keep first occurrence)
-func deduplicateTypes(content string) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-	seen := make(map[string]bool)
-	skip := false
-	var pendingComment []string
-
-	for _, line := range lines {
-		// Detect type definition start
-		if strings.HasPrefix(line, "export interface ") || strings.HasPrefix(line, "export type ") {
-			typeName := extractTypeName(line)
-			if seen[typeName] {
-				skip = true
-				pendingComment = nil
-				continue
-			}
-			seen[typeName] = true
-			skip = false
-			result = append(result, pendingComment...)
-			pendingComment = nil
-			result = append(result, line)
+	clashing := make(map[string]struct{})
+	for _, st := range scan.types {
+		var newName string
+		switch {
+		case opts.Namer != nil:
+			// An empty name keeps the Go name, as returning ctx.Name does.
+			newName = cmp.Or(opts.Namer(st.nameContext()), st.name)
+		case st.kind == KindStruct:
+			newName = mapper(st.name, st.moduleName)
+		}
+		if newName == "" {
 			continue
 		}
-
-		// Collect comments (may belong to next type)
-		if strings.HasPrefix(line, "// From ") || strings.HasPrefix(line, "/**") ||
-			strings.HasPrefix(line, " *") || strings.HasPrefix(line, " */") {
-			if skip {
-				continue
-			}
-			pendingComment = append(pendingComment, line)
+		oldName := st.key
+		if newName == oldName {
 			continue
 		}
-
-		// Empty line
-		if line == "" {
-			if skip {
-				skip = false
-				continue
+		if existing, ok := seenNew[newName]; ok && existing != oldName {
+			if !dropCollisions {
+				return nil, fmt.Errorf("type name mapper collision: %s (%s) and %s (%s) -> %s",
+					existing, scan.positions[existing], oldName, scan.positions[oldName], newName)
 			}
-			if len(pendingComment) > 0 {
-				pendingComment = append(pendingComment, line)
-			} else {
-				result = append(result, line)
-			}
-			continue
-		}
-
-		// Type content
-		if skip {
-			continue
-		}
-		result = append(result, line)
-	}
-
-	return strings.Join(result, "\n")
-}
-
-func extractTypeName(line string) string {
-	line = strings.TrimPrefix(line, "export interface ")
-	line = strings.TrimPrefix(line, "export type ")
-	for i, ch := range line {
-		if ch == ' ' || ch == '{' || ch == '=' || ch == '<' {
-			return line[:i]
+			clashing[newName] = struct{}{}
 		}
+		seenNew[newName] = oldName
+		renames[oldName] = newName
 	}
-	return line
-}
 
-func extractExportName(line string) string {
-	prefixes := []string{
-		"export interface ",
-		"export type ",
-		"export const ",
-		"export enum ",
-		"export class ",
-	}
-	for _, prefix := range prefixes {
-		if strings.HasPrefix(line, prefix) {
-			rest := strings.TrimPrefix(line, prefix)
-			for i, ch := range rest {
-				if ch == ' ' || ch == '{' || ch == '=' || ch == '<' || ch == '(' {
-					return rest[:i]
-				}
-			}
-			return rest
+	for oldName, newName := range renames {
+		if _, ok := clashing[newName]; ok {
+			delete(renames, oldName)
 		}
 	}
-	return ""
+	return renames, nil
 }
 
 func prefixForImportPath(pkgImportPath, importPath string) string {
@@ -813,17 +788,6 @@ func collectPrefixes(pkgImportPath string, packages []packageInfo) []string {
 	return prefixes
 }
 
-func stripPrefixes(content string, prefixes []string) string {
-	if len(prefixes) == 0 {
-		return content
-	}
-
-	re := regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\b`)
-	return re.ReplaceAllStringFunc(content, func(token string) string {
-		return stripPrefixToken(token, prefixes)
-	})
-}
-
 func stripPrefixToken(token string, prefixes []string) string {
 	for _, prefix := range prefixes {
 		if strings.HasPrefix(token, prefix) {
@@ -155,6 +155,72 @@ type Bar struct {
 	}
 }
 
+func TestGenerateTypes_StripPrefixOnlyTouchesIdentifiers(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type Mode string
+
+const (
+	ModeBar Mode = "foo_Bar"
+)
+
+// Bar mentions foo_Bar in its comment.
+type Bar struct {
+	Mode Mode
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateTypesWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+
+	if !strings.Contains(output, `export type Mode = "foo_Bar";`) {
+		t.Fatalf("expected string literal to keep its prefix:\n%s", output)
+	}
+	if !strings.Contains(output, "Bar mentions foo_Bar") {
+		t.Fatalf("expected comment to be left untouched:\n%s", output)
+	}
+	if !strings.Contains(output, "readonly Mode: Mode;") {
+		t.Fatalf("expected reference to be stripped:\n%s", output)
+	}
+}
+
+func TestGenerateTypes_LintCommentsPrecedeSource(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/page.go", `package foo
+
+// Page is a page.
+type Page[T any] struct {
+	Items []T
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateTypesWithOptions(Options{PkgDir: filepath.Join(root, "pkg")})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "// Code generated by 'guts'. DO NOT EDIT.\n\n// biome-ignore lint") {
+		t.Fatalf("expected the guts header followed by the lint comment:\n%s", output)
+	}
+	if !strings.Contains(output, "\n// From foo/page.go\n/**\n * Page is a page.\n */\nexport interface Page") {
+		t.Fatalf("expected source and doc comments right above the declaration:\n%s", output)
+	}
+}
+
 func writeFile(t *testing.T, root, rel, content string) {
 	t.Helper()
 	path := filepath.Join(root, rel)
@@ -165,3 +231,11 @@ func writeFile(t *testing.T, root, rel, content string) {
 		t.Fatalf("write file: %v", err)
 	}
 }
+
+// enterModule runs the test from root so packages.Load resolves its go.mod.
+func enterModule(t *testing.T, root string) {
+	t.Helper()
+	t.Chdir(root)
+	t.Setenv("GOMOD", filepath.Join(root, "go.mod"))
+	t.Setenv("GOWORK", "off")
+}
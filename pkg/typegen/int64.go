@@ -0,0 +1,224 @@
+package typegen
+
+import (
+	"fmt"
+	"go/ast"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+// Int64Mode selects the TypeScript type of int64 and uint64 values, which
+// JavaScript numbers cannot hold above 2^53.
+type Int64Mode string
+
+const (
+	// Int64Number keeps number. It is the default.
+	Int64Number Int64Mode = "number"
+	// Int64AsString emits string, for values marshalled as JSON strings.
+	Int64AsString Int64Mode = "string"
+	// Int64BigInt emits bigint, for clients parsing JSON with a
+	// bigint-aware parser.
+	Int64BigInt Int64Mode = "bigint"
+	// Int64Branded emits the branded Int64String type, a string that cannot
+	// be mixed up with other strings.
+	Int64Branded Int64Mode = "branded"
+)
+
+// int64BrandName is the name of the type declared for Int64Branded.
+const int64BrandName = "Int64String"
+
+func validateInt64Mode(mode Int64Mode) error {
+	switch mode {
+	case "", Int64Number, Int64AsString, Int64BigInt, Int64Branded:
+		return nil
+	default:
+		return fmt.Errorf("unknown int64 mode %q", mode)
+	}
+}
+
+// applyInt64 retypes fields tagged json:",string" as strings, and int64 and
+// uint64 values in struct fields and type declarations as mode says.
+// int64Replacement returns the type of int64 and uint64 values in mode, or
+// nil when they stay number.
+func int64Replacement(mode Int64Mode) func() bindings.ExpressionType {
+	switch mode {
+	case Int64AsString:
+		return func() bindings.ExpressionType { return keyword(bindings.KeywordString) }
+	case Int64BigInt:
+		return func() bindings.ExpressionType { return keyword(bindings.KeywordBigInt) }
+	case Int64Branded:
+		return func() bindings.ExpressionType {
+			return bindings.Reference(bindings.Identifier{Name: int64BrandName})
+		}
+	default:
+		return nil
+	}
+}
+
+// declareInt64Brand adds the Int64String declaration afterwards.
+func applyInt64(decls []*declaration, scan *sourceScan, mode Int64Mode) {
+	replacement := int64Replacement(mode)
+	types := make(map[string]scannedType, len(scan.types))
+	for _, st := range scan.types {
+		types[st.key] = st
+	}
+
+	for _, decl := range decls {
+		st, ok := types[decl.key]
+		if !ok {
+			continue
+		}
+		switch node := decl.node.(type) {
+		case *bindings.Interface:
+			byName := make(map[string]scannedProperty, len(st.properties))
+			for _, prop := range st.properties {
+				byName[prop.name] = prop
+			}
+			for _, field := range node.Fields {
+				prop, ok := byName[field.Name]
+				if !ok {
+					continue
+				}
+				value, _ := prop.tag.Lookup("json")
+				_, options, _ := strings.Cut(value, ",")
+				if slices.Contains(strings.Split(options, ","), "string") {
+					field.Type = quotedType(prop.goType, field.Type, mode == Int64Branded)
+				} else if replacement != nil {
+					field.Type = retypeInt64(prop.goType, field.Type, replacement)
+				}
+			}
+		case *bindings.Alias:
+			// Enums of int64 keep their literal members.
+			if replacement != nil && st.kind != KindEnum {
+				node.Type = retypeInt64(st.goType, node.Type, replacement)
+			}
+		default:
+			continue
+		}
+		decl.refs = referencedKeys(decl.node)
+	}
+}
+
+// declareInt64Brand adds the Int64String declaration when decls use it.
+func declareInt64Brand(decls []*declaration) []*declaration {
+	if !slices.ContainsFunc(decls, func(decl *declaration) bool {
+		return slices.Contains(decl.refs, int64BrandName)
+	}) {
+		return decls
+	}
+	decls = append(decls, brandedDeclaration(int64BrandName, keyword(bindings.KeywordString)))
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].key < decls[j].key
+	})
+	return decls
+}
+
+// quotedType is the type of a field with the json string option, which
+// quotes numbers and booleans, also behind a pointer.
+func quotedType(expr ast.Expr, typ bindings.ExpressionType, branded bool) bindings.ExpressionType {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if union, ok := typ.(*bindings.UnionType); ok {
+		types := slices.Clone(union.Types)
+		for i, t := range types {
+			types[i] = quotedType(expr, t, branded)
+		}
+		return bindings.Union(types...)
+	}
+	kw, ok := typ.(*bindings.LiteralKeyword)
+	if !ok || (*kw != bindings.KeywordNumber && *kw != bindings.KeywordBoolean) {
+		return typ
+	}
+	if branded && isInt64(expr) {
+		return bindings.Reference(bindings.Identifier{Name: int64BrandName})
+	}
+	return keyword(bindings.KeywordString)
+}
+
+// retypeInt64 walks the Go type expr alongside the TypeScript type guts made
+// of it, replacing the number of every int64 and uint64.
+func retypeInt64(expr ast.Expr, typ bindings.ExpressionType, replacement func() bindings.ExpressionType) bindings.ExpressionType {
+	if paren, ok := expr.(*ast.ParenExpr); ok {
+		return retypeInt64(paren.X, typ, replacement)
+	}
+	switch t := typ.(type) {
+	case *bindings.OperatorNodeType:
+		t.Type = retypeInt64(expr, t.Type, replacement)
+		return t
+	case *bindings.UnionType:
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		}
+		for i, member := range t.Types {
+			t.Types[i] = retypeInt64(expr, member, replacement)
+		}
+		return t
+	}
+
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return retypeInt64(e.X, typ, replacement)
+	case *ast.ArrayType:
+		if array, ok := typ.(*bindings.ArrayType); ok {
+			array.Node = retypeInt64(e.Elt, array.Node, replacement)
+		}
+	case *ast.MapType:
+		if record, ok := recordType(typ); ok {
+			record.Arguments[1] = retypeInt64(e.Value, record.Arguments[1], replacement)
+		}
+	case *ast.Ident:
+		if kw, ok := typ.(*bindings.LiteralKeyword); ok && *kw == bindings.KeywordNumber && isInt64(e) {
+			return replacement()
+		}
+	}
+	return typ
+}
+
+func isInt64(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && (ident.Name == "int64" || ident.Name == "uint64")
+}
+
+// brandedDeclaration declares name as a branded base type.
+func brandedDeclaration(name string, base bindings.ExpressionType) *declaration {
+	node := &bindings.Alias{
+		Name:      bindings.Identifier{Name: name},
+		Modifiers: []bindings.Modifier{bindings.ModifierExport},
+		Type:      brandedType(name, base),
+	}
+	return &declaration{key: name, name: name, node: node}
+}
+
+// brandedType is base & { readonly __brand: "brand" }.
+func brandedType(brand string, base bindings.ExpressionType) bindings.ExpressionType {
+	return &bindings.TypeIntersection{Types: []bindings.ExpressionType{
+		base,
+		&bindings.TypeLiteralNode{Members: []*bindings.PropertySignature{{
+			Name:      "__brand",
+			Modifiers: []bindings.Modifier{bindings.ModifierReadonly},
+			Type:      &bindings.LiteralType{Value: brand},
+		}}},
+	}}
+}
+
+// brandOf reports whether t is a branded type made by brandedDeclaration
+// or brandedType, returning its base type and brand.
+func brandOf(t *bindings.TypeIntersection) (bindings.ExpressionType, string, bool) {
+	if len(t.Types) != 2 {
+		return nil, "", false
+	}
+	lit, ok := t.Types[1].(*bindings.TypeLiteralNode)
+	if !ok || len(lit.Members) != 1 || lit.Members[0].Name != "__brand" {
+		return nil, "", false
+	}
+	value, ok := lit.Members[0].Type.(*bindings.LiteralType)
+	if !ok {
+		return nil, "", false
+	}
+	brand, ok := value.Value.(string)
+	return t.Types[0], brand, ok
+}
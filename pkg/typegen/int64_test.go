@@ -0,0 +1,136 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_Int64(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+type UserID int64
+
+type User struct {
+	ID      UserID           `+"`json:\"id\"`"+`
+	Parent  *int64           `+"`json:\"parent\"`"+`
+	Version int64            `+"`json:\"version,string\"`"+`
+	Active  bool             `+"`json:\"active,string\"`"+`
+	Scores  map[string]int64 `+"`json:\"scores\"`"+`
+	Age     int              `+"`json:\"age\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{"readonly version: string;", "readonly active: string;", "readonly parent: number | null;", "type UserID = number;"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	opts.Int64 = Int64BigInt
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (bigint): %v", err)
+	}
+	for _, want := range []string{"readonly parent: bigint | null;", "readonly scores: Record<string, bigint>;", "readonly age: number;", "type UserID = bigint;"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	opts.Int64 = Int64Branded
+	opts.IncludeType = "^User$"
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (branded): %v", err)
+	}
+	for _, want := range []string{
+		"export type Int64String = string & {",
+		`readonly __brand: "Int64String";`,
+		"readonly version: Int64String;",
+		"type UserID = Int64String;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	zod, err := GenerateZodWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateZodWithOptions: %v", err)
+	}
+	if !strings.Contains(zod, `Int64StringSchema = z.string().brand<"Int64String">();`) {
+		t.Fatalf("expected a zod brand:\n%s", zod)
+	}
+}
+
+func TestGenerateTypes_Int64InSignatures(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+import "context"
+
+type CountRequest struct {
+	Since int64 `+"`json:\"since\"`"+`
+}
+
+//typegen:rpc
+type UserService interface {
+	Get(ctx context.Context, id int64) (uint64, error)
+	Ages(ctx context.Context, ids []int64) (map[string]int, error)
+}
+
+//typegen:route POST /users/count
+func Count(ctx context.Context, req CountRequest) ([]int64, error) {
+	return nil, nil
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true, Int64: Int64AsString}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"readonly since: string;",
+		"Get(id: string): Promise<string>;",
+		"Ages(ids: string[]): Promise<Record<string, number>>;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	client, err := GenerateClientWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateClientWithOptions: %v", err)
+	}
+	if !strings.Contains(client, "Promise<string[]>") {
+		t.Fatalf("expected the int64 response as string[]:\n%s", client)
+	}
+
+	opts.Int64 = Int64Branded
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (branded): %v", err)
+	}
+	for _, want := range []string{"export type Int64String = string & {", "Get(id: Int64String): Promise<Int64String>;"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package typegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateJSONSchemaWithOptions generates a JSON Schema (draft 2020-12)
+// document for the declarations that GenerateTypesWithOptions would emit.
+// Every declaration is stored under $defs using its TypeScript name, fields
+// without omitempty are required, and Go doc comments become descriptions.
+func GenerateJSONSchemaWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderJSONSchema(gen)
+}
+
+func renderJSONSchema(gen *generation) (string, error) {
+	defs, err := newSchemaWriter(gen, "#/$defs/").definitions()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(map[string]any{
+		"$schema": jsonSchemaDialect,
+		"$defs":   defs,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal json schema: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// schemaWriter converts guts declarations to JSON Schema objects. It is shared
+// by the JSON Schema and OpenAPI backends, which differ only in refPrefix.
+type schemaWriter struct {
+	gen       *generation
+	refPrefix string
+	// generics holds the type parameters of generic declarations by name, so
+	// references with type arguments can be expanded inline.
+	generics map[string][]*bindings.TypeParameter
+	// bound maps type parameter refs to the schemas of their arguments while
+	// expanding a generic reference, and boundNames to the parts they add to
+	// instantiation names.
+	bound      map[string]map[string]any
+	boundNames map[string]string
+	// instances holds a definition per generic instantiation, e.g. Tree_string.
+	// An entry is nil while the instantiation is being expanded, so recursive
+	// generics end in a $ref to it.
+	instances map[string]map[string]any
+}
+
+func newSchemaWriter(gen *generation, refPrefix string) *schemaWriter {
+	w := &schemaWriter{
+		gen:       gen,
+		refPrefix: refPrefix,
+		generics:  make(map[string][]*bindings.TypeParameter),
+		instances: make(map[string]map[string]any),
+	}
+	for _, decl := range gen.decls {
+		switch node := decl.node.(type) {
+		case *bindings.Interface:
+			if len(node.Parameters) > 0 {
+				w.generics[decl.name] = node.Parameters
+			}
+		case *bindings.Alias:
+			if len(node.Parameters) > 0 {
+				w.generics[decl.name] = node.Parameters
+			}
+		}
+	}
+	return w
+}
+
+// definitions returns one schema per exported declaration, keyed by name.
+func (w *schemaWriter) definitions() (map[string]any, error) {
+	defs := make(map[string]any)
+	for _, decl := range w.gen.decls {
+		// RPC contracts have no schema counterpart.
+		if decl.rpc {
+			continue
+		}
+		schema, err := w.declaration(decl.node)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", decl.name, err)
+		}
+		if schema != nil {
+			defs[decl.name] = schema
+		}
+	}
+	for name, schema := range w.instances {
+		defs[name] = schema
+	}
+	return defs, nil
+}
+
+func (w *schemaWriter) declaration(node bindings.Node) (map[string]any, error) {
+	switch node := node.(type) {
+	case *bindings.Interface:
+		schema := w.object(node.Fields)
+		if len(node.Heritage) > 0 {
+			var all []any
+			for _, heritage := range node.Heritage {
+				for _, arg := range heritage.Args {
+					all = append(all, w.expr(arg))
+				}
+			}
+			schema = map[string]any{"allOf": append(all, schema)}
+		}
+		return setDescription(schema, node.Comments()), nil
+	case *bindings.Alias:
+		return setDescription(w.expr(node.Type), node.Comments()), nil
+	case *bindings.Enum:
+		members := make([]bindings.ExpressionType, 0, len(node.Members))
+		for _, member := range node.Members {
+			members = append(members, member.Value)
+		}
+		return w.expr(bindings.Union(members...)), nil
+	case *bindings.VariableStatement:
+		// Constants have no schema counterpart.
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported declaration %T", node)
+	}
+}
+
+func (w *schemaWriter) object(fields []*bindings.PropertySignature) map[string]any {
+	properties := make(map[string]any, len(fields))
+	var required []string
+	for _, field := range fields {
+		properties[field.Name] = setDescription(w.expr(field.Type), field.Comments())
+		if !field.QuestionToken {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (w *schemaWriter) expr(e bindings.ExpressionType) map[string]any {
+	switch e := e.(type) {
+	case *bindings.LiteralKeyword:
+		return schemaKeyword(*e)
+	case *bindings.LiteralType:
+		return map[string]any{"const": e.Value}
+	case *bindings.Null:
+		return map[string]any{"type": "null"}
+	case *bindings.ArrayType:
+		return map[string]any{"type": "array", "items": w.expr(e.Node)}
+	case *bindings.TupleType:
+		items := make([]any, 0, e.Length)
+		for i := 0; i < e.Length; i++ {
+			items = append(items, w.expr(e.Node))
+		}
+		return map[string]any{
+			"type":        "array",
+			"prefixItems": items,
+			"minItems":    e.Length,
+			"maxItems":    e.Length,
+		}
+	case *bindings.OperatorNodeType:
+		if e.Keyword == bindings.KeywordKeyOf {
+			return map[string]any{"type": "string"}
+		}
+		return w.expr(e.Type)
+	case *bindings.UnionType:
+		return w.union(e)
+	case *bindings.TypeIntersection:
+		// Brands only exist at compile time.
+		if base, _, ok := brandOf(e); ok {
+			return w.expr(base)
+		}
+		all := make([]any, 0, len(e.Types))
+		for _, t := range e.Types {
+			all = append(all, w.expr(t))
+		}
+		return map[string]any{"allOf": all}
+	case *bindings.TypeLiteralNode:
+		return w.object(e.Members)
+	case *bindings.ReferenceType:
+		return w.reference(e)
+	case *bindings.ExpressionWithTypeArguments:
+		return w.expr(e.Expression)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (w *schemaWriter) union(u *bindings.UnionType) map[string]any {
+	var nullable bool
+	var types []bindings.ExpressionType
+	for _, t := range u.Types {
+		if _, ok := t.(*bindings.Null); ok {
+			nullable = true
+			continue
+		}
+		types = append(types, t)
+	}
+
+	if values, ok := literalValues(types); ok {
+		if nullable {
+			values = append(values, nil)
+		}
+		return map[string]any{"enum": values}
+	}
+
+	var schemas []any
+	for _, t := range types {
+		schemas = append(schemas, w.expr(t))
+	}
+	if nullable {
+		schemas = append(schemas, map[string]any{"type": "null"})
+	}
+	if len(schemas) == 1 {
+		return schemas[0].(map[string]any)
+	}
+	return map[string]any{"anyOf": schemas}
+}
+
+func (w *schemaWriter) reference(ref *bindings.ReferenceType) map[string]any {
+	if ref.Name.Name == "Record" && ref.Name.Package == nil && len(ref.Arguments) == 2 {
+		schema := map[string]any{
+			"type":                 "object",
+			"additionalProperties": w.expr(ref.Arguments[1]),
+		}
+		if key := w.expr(ref.Arguments[0]); key["type"] != "string" && key["type"] != "number" {
+			schema["propertyNames"] = key
+		}
+		return schema
+	}
+
+	name := ref.Name.Ref()
+	if schema, ok := w.bound[name]; ok {
+		return schema
+	}
+
+	params, generic := w.generics[name]
+	if generic && len(ref.Arguments) > 0 {
+		// JSON Schema has no generics; every instantiation gets its own
+		// definition.
+		instance := w.instanceName(ref)
+		if _, ok := w.instances[instance]; !ok {
+			bound := make(map[string]map[string]any, len(params))
+			boundNames := make(map[string]string, len(params))
+			for i, param := range params {
+				if i < len(ref.Arguments) {
+					bound[param.Name.Ref()] = w.expr(ref.Arguments[i])
+					boundNames[param.Name.Ref()] = w.argumentName(ref.Arguments[i])
+				}
+			}
+			w.instances[instance] = nil
+			prevBound, prevNames := w.bound, w.boundNames
+			w.bound, w.boundNames = bound, boundNames
+			schema, err := w.declaration(w.gen.byName[name].node)
+			w.bound, w.boundNames = prevBound, prevNames
+			if err != nil || schema == nil {
+				schema = map[string]any{}
+			}
+			w.instances[instance] = schema
+		}
+		return map[string]any{"$ref": w.refPrefix + instance}
+	}
+
+	if _, ok := w.gen.byName[name]; !ok {
+		return map[string]any{}
+	}
+	return map[string]any{"$ref": w.refPrefix + name}
+}
+
+func schemaKeyword(k bindings.LiteralKeyword) map[string]any {
+	switch k {
+	case bindings.KeywordString:
+		return map[string]any{"type": "string"}
+	case bindings.KeywordNumber:
+		return map[string]any{"type": "number"}
+	case bindings.KeywordBigInt:
+		return map[string]any{"type": "integer"}
+	case bindings.KeywordBoolean:
+		return map[string]any{"type": "boolean"}
+	case bindings.KeywordObject:
+		return map[string]any{"type": "object"}
+	case bindings.KeywordNever:
+		return map[string]any{"not": map[string]any{}}
+	default:
+		return map[string]any{}
+	}
+}
+
+// literalValues reports whether every type is a literal, as produced by
+// config.EnumAsTypes.
+func literalValues(types []bindings.ExpressionType) ([]any, bool) {
+	if len(types) == 0 {
+		return nil, false
+	}
+	values := make([]any, 0, len(types))
+	for _, t := range types {
+		lit, ok := t.(*bindings.LiteralType)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, lit.Value)
+	}
+	return values, true
+}
+
+// setDescription returns schema with the Go doc comments as its
+// description, copying it first since schemas of type arguments are shared.
+// References are left alone because siblings of $ref are easy to misread.
+func setDescription(schema map[string]any, comments []bindings.SyntheticComment) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return schema
+	}
+	text := docText(comments)
+	if text == "" {
+		return schema
+	}
+	described := maps.Clone(schema)
+	described["description"] = text
+	return described
+}
+
+// instanceName names the definition of a generic instantiation after the
+// declaration and its type arguments, e.g. Tree_string or Page_User.
+func (w *schemaWriter) instanceName(ref *bindings.ReferenceType) string {
+	parts := []string{ref.Name.Ref()}
+	for _, arg := range ref.Arguments {
+		parts = append(parts, w.argumentName(arg))
+	}
+	name := strings.Join(parts, "_")
+	for {
+		if _, taken := w.gen.byName[name]; !taken {
+			return name
+		}
+		name += "_"
+	}
+}
+
+func (w *schemaWriter) argumentName(e bindings.ExpressionType) string {
+	switch e := e.(type) {
+	case *bindings.LiteralKeyword:
+		return strings.ToLower(strings.TrimSuffix(string(*e), "Keyword"))
+	case *bindings.Null:
+		return "null"
+	case *bindings.ArrayType:
+		return w.argumentName(e.Node) + "Array"
+	case *bindings.OperatorNodeType:
+		return w.argumentName(e.Type)
+	case *bindings.UnionType:
+		names := make([]string, len(e.Types))
+		for i, t := range e.Types {
+			names[i] = w.argumentName(t)
+		}
+		return strings.Join(names, "Or")
+	case *bindings.ReferenceType:
+		if name, ok := w.boundNames[e.Name.Ref()]; ok {
+			return name
+		}
+		if len(e.Arguments) > 0 {
+			return w.instanceName(e)
+		}
+		return e.Name.Ref()
+	default:
+		return "unknown"
+	}
+}
+
+// docText joins the Go doc comments captured by PreserveComments, skipping
+// the synthetic ones guts adds for lint directives.
+func docText(comments []bindings.SyntheticComment) string {
+	var lines []string
+	for _, c := range comments {
+		if c.DoNotFormat {
+			continue
+		}
+		for _, line := range strings.Split(c.Text, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "* ")
+			if line == "" || line == "*" {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,141 @@
+package typegen
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateJSONSchema_DefsAndRequired(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+// FooReq creates a foo.
+type FooReq struct {
+	// Name is shown to users.
+	Name string `+"`json:\"name\"`"+`
+	Note string `+"`json:\"note,omitempty\"`"+`
+	Bar  *Bar   `+"`json:\"bar\"`"+`
+}
+
+type Bar struct {
+	Kind Kind `+"`json:\"kind\"`"+`
+}
+
+type Kind string
+
+const (
+	KindA Kind = "a"
+	KindB Kind = "b"
+)
+
+type Unused struct{}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateJSONSchemaWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		IncludeType: `Req$`,
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateJSONSchemaWithOptions: %v", err)
+	}
+
+	var doc struct {
+		Schema string                    `json:"$schema"`
+		Defs   map[string]map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, output)
+	}
+
+	if doc.Schema != jsonSchemaDialect {
+		t.Fatalf("unexpected $schema %q", doc.Schema)
+	}
+	if _, ok := doc.Defs["Unused"]; ok {
+		t.Fatalf("did not expect Unused outside the dependency closure")
+	}
+
+	foo := doc.Defs["FooReq"]
+	if foo["description"] != "FooReq creates a foo." {
+		t.Fatalf("unexpected description %v", foo["description"])
+	}
+	if got := foo["required"]; !reflect.DeepEqual(got, []any{"name", "bar"}) {
+		t.Fatalf("unexpected required %v", got)
+	}
+	props := foo["properties"].(map[string]any)
+	name := props["name"].(map[string]any)
+	if name["description"] != "Name is shown to users." {
+		t.Fatalf("unexpected field description %v", name["description"])
+	}
+	bar := props["bar"].(map[string]any)
+	wantBar := map[string]any{"anyOf": []any{
+		map[string]any{"$ref": "#/$defs/Bar"},
+		map[string]any{"type": "null"},
+	}}
+	if !reflect.DeepEqual(bar, wantBar) {
+		t.Fatalf("unexpected bar schema %v", bar)
+	}
+	if got := doc.Defs["Kind"]["enum"]; !reflect.DeepEqual(got, []any{"a", "b"}) {
+		t.Fatalf("unexpected Kind enum %v", got)
+	}
+}
+
+func TestGenerateJSONSchema_RecursiveGenerics(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/tree.go", `package foo
+
+type Tree[T any] struct {
+	// Value is the node value.
+	Value    T         `+"`json:\"value\"`"+`
+	Children []Tree[T] `+"`json:\"children\"`"+`
+}
+
+type Forest struct {
+	Names Tree[string] `+"`json:\"names\"`"+`
+	// Plain is a bare string.
+	Plain string `+"`json:\"plain\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateJSONSchemaWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		IncludeType: `^Forest$`,
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateJSONSchemaWithOptions: %v", err)
+	}
+
+	var doc struct {
+		Defs map[string]map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, output)
+	}
+
+	props := doc.Defs["Forest"]["properties"].(map[string]any)
+	if got := props["names"]; !reflect.DeepEqual(got, map[string]any{"$ref": "#/$defs/Tree_string"}) {
+		t.Fatalf("unexpected names schema %v", got)
+	}
+	tree := doc.Defs["Tree_string"]["properties"].(map[string]any)
+	wantChildren := map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/Tree_string"}}
+	if got := tree["children"]; !reflect.DeepEqual(got, wantChildren) {
+		t.Fatalf("unexpected children schema %v", got)
+	}
+	if got := tree["value"]; !reflect.DeepEqual(got, map[string]any{"type": "string", "description": "Value is the node value."}) {
+		t.Fatalf("unexpected value schema %v", got)
+	}
+	if got := props["plain"]; !reflect.DeepEqual(got, map[string]any{"type": "string", "description": "Plain is a bare string."}) {
+		t.Fatalf("unexpected plain schema %v", got)
+	}
+}
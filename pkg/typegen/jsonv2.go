@@ -0,0 +1,196 @@
+package typegen
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+// applyJSONv2 rewrites struct declarations to what encoding/json/v2
+// marshals, driven by the options of their json tags:
+//
+//   - inline fields of struct type become extends clauses, and inline or
+//     unknown map fields become a Record index signature;
+//   - format options change the type: unix times and numeric durations are
+//     numbers, format:array byte slices number arrays, other formats
+//     strings, and format:emitnull / format:emitempty decide nullability;
+//   - omitempty only omits empty JSON values, so it no longer makes number
+//     and boolean properties optional.
+//
+// Case options only affect decoding and leave names alone.
+func applyJSONv2(decls []*declaration, scan *sourceScan) {
+	props := make(map[string][]scannedProperty)
+	for _, st := range scan.types {
+		if len(st.properties) > 0 {
+			props[st.key] = st.properties
+		}
+	}
+
+	for _, decl := range decls {
+		iface, ok := decl.node.(*bindings.Interface)
+		if !ok || len(props[decl.key]) == 0 {
+			continue
+		}
+		byName := make(map[string]scannedProperty, len(props[decl.key]))
+		for _, prop := range props[decl.key] {
+			byName[prop.name] = prop
+		}
+
+		var extends []bindings.ExpressionType
+		kept := iface.Fields[:0]
+		for _, field := range iface.Fields {
+			prop, ok := byName[field.Name]
+			if !ok {
+				kept = append(kept, field)
+				continue
+			}
+			value, _ := prop.tag.Lookup("json")
+			_, options, _ := strings.Cut(value, ",")
+			flags := splitJSONOptions(options)
+
+			if slices.Contains(flags, "inline") || slices.Contains(flags, "unknown") {
+				if base := inlinedType(field.Type, slices.Contains(flags, "unknown")); base != nil {
+					extends = append(extends, base)
+					continue
+				}
+			}
+			for _, flag := range flags {
+				if format, ok := strings.CutPrefix(flag, "format:"); ok {
+					applyJSONFormat(field, strings.Trim(format, "'"))
+				}
+			}
+			if field.QuestionToken && !slices.Contains(flags, "omitzero") && isScalar(field.Type) {
+				field.QuestionToken = false
+			}
+			kept = append(kept, field)
+		}
+		iface.Fields = kept
+
+		if len(extends) > 0 {
+			if len(iface.Heritage) > 0 && iface.Heritage[0].Token == bindings.HeritageTypeExtends {
+				iface.Heritage[0].Args = append(iface.Heritage[0].Args, extends...)
+			} else {
+				iface.Heritage = append(iface.Heritage, bindings.HeritageClauseExtends(extends...))
+			}
+		}
+		decl.refs = referencedKeys(decl.node)
+	}
+}
+
+// splitJSONOptions splits the options of a json tag. Quoted format layouts
+// may contain commas.
+func splitJSONOptions(options string) []string {
+	var flags []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(options); i++ {
+		switch options[i] {
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				flags = append(flags, options[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if options != "" {
+		flags = append(flags, options[start:])
+	}
+	return flags
+}
+
+// inlinedType returns what an interface extends to inline a field of type
+// typ: the struct itself, or Record<string, V> for maps. Unknown fields of
+// other types, such as jsontext.Value, accept any member.
+func inlinedType(typ bindings.ExpressionType, unknown bool) bindings.ExpressionType {
+	typ = nonNullType(unwrapReadonly(typ))
+	if ref, ok := recordType(typ); ok {
+		return bindings.Reference(ref.Name, keyword(bindings.KeywordString), ref.Arguments[1])
+	}
+	if ref, ok := typ.(*bindings.ReferenceType); ok && !unknown {
+		return ref
+	}
+	if unknown {
+		return bindings.Reference(bindings.Identifier{Name: "Record"}, keyword(bindings.KeywordString), keyword(bindings.KeywordUnknown))
+	}
+	return nil
+}
+
+func applyJSONFormat(field *bindings.PropertySignature, format string) {
+	readonly := slices.Contains(field.Modifiers, bindings.ModifierReadonly)
+	switch format {
+	case "emitnull":
+		if !isNullable(field.Type) {
+			field.Type = bindings.Union(field.Type, &bindings.Null{})
+		}
+	case "emitempty":
+		field.Type = nonNullType(field.Type)
+	case "unix", "unixmilli", "unixmicro", "unixnano", "sec", "milli", "micro", "nano":
+		field.Type = keyword(bindings.KeywordNumber)
+	case "array":
+		var typ bindings.ExpressionType = bindings.Array(keyword(bindings.KeywordNumber))
+		if readonly {
+			typ = bindings.OperatorNode(bindings.KeywordReadonly, typ)
+		}
+		field.Type = typ
+	default:
+		// Binary encodings (base64, base32, hex), duration units and time
+		// layouts all marshal to strings.
+		field.Type = keyword(bindings.KeywordString)
+	}
+}
+
+// recordType reports whether typ is Record<K, V>.
+func recordType(typ bindings.ExpressionType) (*bindings.ReferenceType, bool) {
+	ref, ok := typ.(*bindings.ReferenceType)
+	if !ok || ref.Name.Name != "Record" || ref.Name.Package != nil || len(ref.Arguments) != 2 {
+		return nil, false
+	}
+	return ref, true
+}
+
+func unwrapReadonly(typ bindings.ExpressionType) bindings.ExpressionType {
+	if op, ok := typ.(*bindings.OperatorNodeType); ok && op.Keyword == bindings.KeywordReadonly {
+		return op.Type
+	}
+	return typ
+}
+
+func isNullable(typ bindings.ExpressionType) bool {
+	union, ok := typ.(*bindings.UnionType)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(union.Types, func(t bindings.ExpressionType) bool {
+		_, null := t.(*bindings.Null)
+		return null
+	})
+}
+
+// nonNullType removes null from a union.
+func nonNullType(typ bindings.ExpressionType) bindings.ExpressionType {
+	union, ok := typ.(*bindings.UnionType)
+	if !ok {
+		return typ
+	}
+	types := slices.DeleteFunc(slices.Clone(union.Types), func(t bindings.ExpressionType) bool {
+		_, null := t.(*bindings.Null)
+		return null
+	})
+	if len(types) == 1 {
+		return types[0]
+	}
+	return bindings.Union(types...)
+}
+
+// isScalar reports whether typ is a number or boolean, which never
+// marshal to an empty JSON value.
+func isScalar(typ bindings.ExpressionType) bool {
+	kw, ok := typ.(*bindings.LiteralKeyword)
+	if !ok {
+		return false
+	}
+	return *kw == bindings.KeywordNumber || *kw == bindings.KeywordBoolean || *kw == bindings.KeywordBigInt
+}
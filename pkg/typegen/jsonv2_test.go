@@ -0,0 +1,75 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_JSONv2(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/event/event.go", `package event
+
+import "time"
+
+type Base struct {
+	ID int `+"`json:\"id\"`"+`
+}
+
+type Event struct {
+	Base  `+"`json:\",inline\"`"+`
+	Extra map[string]any `+"`json:\",unknown\"`"+`
+	Raw   []byte         `+"`json:\"raw,format:array\"`"+`
+	At    time.Time      `+"`json:\"at,format:unix\"`"+`
+	Day   time.Time      `+"`json:\"day,format:'2006-01-02'\"`"+`
+	Tags  []string       `+"`json:\"tags,format:emitnull\"`"+`
+	Count int            `+"`json:\"count,omitempty\"`"+`
+	Total int            `+"`json:\"total,omitzero\"`"+`
+	Note  string         `+"`json:\"note,omitempty\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	if !strings.Contains(output, "readonly Base: Base;") {
+		t.Fatalf("expected v1 output to keep the inline field:\n%s", output)
+	}
+
+	opts.JSONv2 = true
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (v2): %v", err)
+	}
+	for _, want := range []string{
+		"interface Event extends Base, Record<string, unknown> {",
+		"readonly raw: readonly number[];",
+		"readonly at: number;",
+		"readonly day: string;",
+		"readonly tags: readonly string[] | null;",
+		"readonly count: number;",
+		"readonly total?: number;",
+		"readonly note?: string;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Extra") {
+		t.Fatalf("expected unknown field to become an index signature:\n%s", output)
+	}
+
+	zod, err := GenerateZodWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateZodWithOptions: %v", err)
+	}
+	if !strings.Contains(zod, ".catchall(z.unknown())") {
+		t.Fatalf("expected unknown members in zod schema:\n%s", zod)
+	}
+}
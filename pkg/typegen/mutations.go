@@ -0,0 +1,108 @@
+package typegen
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/config"
+)
+
+var (
+	mutationsMu sync.RWMutex
+	// mutations holds the built-in guts mutations and those added with
+	// RegisterMutation, by name.
+	mutations = map[string]guts.MutationFunc{
+		"export-types":                          config.ExportTypes,
+		"enum-as-types":                         config.EnumAsTypes,
+		"enum-lists":                            config.EnumLists,
+		"trim-enum-prefix":                      config.TrimEnumPrefix,
+		"read-only":                             config.ReadOnly,
+		"null-union-slices":                     config.NullUnionSlices,
+		"not-null-maps":                         config.NotNullMaps,
+		"simplify-optional":                     config.SimplifyOptional,
+		"simplify-omit-empty":                   config.SimplifyOmitEmpty,
+		"interface-to-type":                     config.InterfaceToType,
+		"no-jsdoc-transform":                    config.NoJSDocTransform,
+		"biome-lint-ignore-any-type-parameters": config.BiomeLintIgnoreAnyTypeParameters,
+	}
+)
+
+// DefaultMutations returns the names of the mutations applied when
+// Options.Mutations is empty, in order.
+func DefaultMutations() []string {
+	return []string{
+		"export-types",
+		"enum-as-types",
+		"read-only",
+		"null-union-slices",
+		"not-null-maps",
+		"biome-lint-ignore-any-type-parameters",
+	}
+}
+
+// RegisterMutation makes a custom mutation available to Options.Mutations
+// and the mutations list of config files under name. It panics if name is
+// empty, starts with "-" or is already registered.
+func RegisterMutation(name string, mutation guts.MutationFunc) {
+	mutationsMu.Lock()
+	defer mutationsMu.Unlock()
+	if name == "" || strings.HasPrefix(name, "-") || mutation == nil {
+		panic(fmt.Sprintf("typegen: invalid mutation %q", name))
+	}
+	if _, dup := mutations[name]; dup {
+		panic(fmt.Sprintf("typegen: mutation %q registered twice", name))
+	}
+	mutations[name] = mutation
+}
+
+// unregisterMutation removes a mutation added with RegisterMutation, so
+// tests can register theirs again when run with -count.
+func unregisterMutation(name string) {
+	mutationsMu.Lock()
+	defer mutationsMu.Unlock()
+	delete(mutations, name)
+}
+
+// resolveMutations looks up names, which either list every mutation to
+// apply in order or, when all of them start with "-", remove mutations
+// from DefaultMutations.
+func resolveMutations(names []string) ([]guts.MutationFunc, error) {
+	removals := len(names) > 0
+	for _, name := range names {
+		removals = removals && strings.HasPrefix(name, "-")
+	}
+	if removals {
+		list := DefaultMutations()
+		for _, name := range names {
+			name = strings.TrimPrefix(name, "-")
+			if !slices.Contains(list, name) {
+				return nil, fmt.Errorf("mutation %q is not a default mutation", name)
+			}
+			list = slices.DeleteFunc(list, func(n string) bool { return n == name })
+		}
+		names = list
+	} else if len(names) == 0 {
+		names = DefaultMutations()
+	}
+
+	mutationsMu.RLock()
+	defer mutationsMu.RUnlock()
+	funcs := make([]guts.MutationFunc, 0, len(names))
+	for _, name := range names {
+		mutation, ok := mutations[name]
+		if !ok {
+			known := make([]string, 0, len(mutations))
+			for n := range mutations {
+				known = append(known, n)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown mutation %q (known: %s)", name, strings.Join(known, ", "))
+		}
+		funcs = append(funcs, mutation)
+	}
+	return funcs, nil
+}
@@ -0,0 +1,71 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+)
+
+func TestGenerateTypes_Mutations(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/form/form.go", `package form
+
+type Status string
+
+const (
+	StatusDraft Status = "draft"
+	StatusSent  Status = "sent"
+)
+
+type FormState struct {
+	Status Status
+	Tags   []string
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	opts.Mutations = []string{"-read-only", "-enum-as-types"}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{"export enum Status {", "    Tags: string[];"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "readonly") {
+		t.Fatalf("expected no readonly modifiers:\n%s", output)
+	}
+
+	RegisterMutation("test-optional-tags", func(ts *guts.Typescript) {
+		ts.ForEach(func(key string, node bindings.Node) {
+			if iface, ok := node.(*bindings.Interface); ok {
+				for _, field := range iface.Fields {
+					field.QuestionToken = field.Name == "Tags"
+				}
+			}
+		})
+	})
+	t.Cleanup(func() { unregisterMutation("test-optional-tags") })
+	opts.Mutations = []string{"export-types", "test-optional-tags"}
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (custom): %v", err)
+	}
+	if !strings.Contains(output, "    Tags?: string[];") {
+		t.Fatalf("expected custom mutation to apply:\n%s", output)
+	}
+
+	opts.Mutations = []string{"no-such-mutation"}
+	if _, err := GenerateTypesWithOptions(opts); err == nil || !strings.Contains(err.Error(), "no-such-mutation") {
+		t.Fatalf("expected unknown mutation error, got %v", err)
+	}
+}
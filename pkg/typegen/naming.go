@@ -0,0 +1,129 @@
+package typegen
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// DeclarationKind classifies an exported Go type declaration.
+type DeclarationKind string
+
+const (
+	// KindStruct is a struct type, emitted as a TypeScript interface.
+	KindStruct DeclarationKind = "struct"
+	// KindEnum is a defined type with constants, emitted as a union.
+	KindEnum DeclarationKind = "enum"
+	// KindMap is a defined map type.
+	KindMap DeclarationKind = "map"
+	// KindSlice is a defined slice or array type.
+	KindSlice DeclarationKind = "slice"
+	// KindUnion is an interface emitted as a discriminated union of its
+	// implementations.
+	KindUnion DeclarationKind = "union"
+	// KindService is an interface emitted as an RPC contract.
+	KindService DeclarationKind = "service"
+	// KindAlias is any other type declaration, such as a defined scalar or a
+	// generic instantiation.
+	KindAlias DeclarationKind = "alias"
+)
+
+// NameContext describes an exported Go type declaration being named.
+type NameContext struct {
+	// Name is the Go name, or the name chosen by the previous Namer of a
+	// ChainNamers chain.
+	Name string
+	Kind DeclarationKind
+	// ImportPath is the import path of the declaring package.
+	ImportPath string
+	// Package is ImportPath relative to PkgDir, "" for PkgDir itself.
+	Package string
+	// File is "<pkg>/<file>", as printed in the "From" header.
+	File string
+	// Doc is the doc comment of the declaration, without comment markers.
+	Doc string
+	// Tags maps the Go field names of a struct to their raw struct tags.
+	Tags map[string]string
+}
+
+// Namer picks the TypeScript name of an exported declaration. Returning ""
+// or ctx.Name keeps the Go name, whatever the declaration kind.
+type Namer func(ctx NameContext) string
+
+// PackagePrefixNamer prefixes names with their package path relative to
+// PkgDir in PascalCase: order/admin.Item becomes OrderAdminItem. Names in
+// PkgDir itself are unchanged.
+func PackagePrefixNamer(ctx NameContext) string {
+	if ctx.Package == "" {
+		return ctx.Name
+	}
+	var b strings.Builder
+	for _, segment := range strings.Split(ctx.Package, "/") {
+		b.WriteString(pascalCase(segment))
+	}
+	return b.String() + ctx.Name
+}
+
+// LastSegmentNamer prefixes names with the last element of their package
+// path in PascalCase: order/admin.Item becomes AdminItem.
+func LastSegmentNamer(ctx NameContext) string {
+	if ctx.Package == "" {
+		return ctx.Name
+	}
+	return pascalCase(path.Base(ctx.Package)) + ctx.Name
+}
+
+// SuffixNamer returns a Namer that rewrites name suffixes, e.g.
+// {"Req": "Request", "Res": "Response"}. The longest matching suffix wins.
+func SuffixNamer(suffixes map[string]string) Namer {
+	keys := make([]string, 0, len(suffixes))
+	for suffix := range suffixes {
+		if suffix != "" {
+			keys = append(keys, suffix)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	return func(ctx NameContext) string {
+		for _, suffix := range keys {
+			if base, ok := strings.CutSuffix(ctx.Name, suffix); ok && base != "" {
+				return base + suffixes[suffix]
+			}
+		}
+		return ctx.Name
+	}
+}
+
+// ChainNamers applies namers in order, each seeing the name chosen by the
+// previous one in ctx.Name.
+func ChainNamers(namers ...Namer) Namer {
+	return func(ctx NameContext) string {
+		for _, namer := range namers {
+			if namer == nil {
+				continue
+			}
+			if name := namer(ctx); name != "" {
+				ctx.Name = name
+			}
+		}
+		return ctx.Name
+	}
+}
+
+// nameContext describes st to a Namer.
+func (st scannedType) nameContext() NameContext {
+	return NameContext{
+		Name:       st.name,
+		Kind:       st.kind,
+		ImportPath: st.importPath,
+		Package:    st.rel,
+		File:       st.file,
+		Doc:        st.doc,
+		Tags:       st.tags,
+	}
+}
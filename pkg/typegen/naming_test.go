@@ -0,0 +1,128 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_Namer(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/order/admin/dto.go", `package admin
+
+type Status string
+
+const (
+	StatusOpen Status = "open"
+)
+
+// Labels are shown next to the order.
+type Labels map[string]string
+
+type ListReq struct {
+	Status Status `+"`json:\"status\"`"+`
+	Labels Labels
+}
+`)
+
+	enterModule(t, root)
+
+	var seen []NameContext
+	output, err := GenerateTypesWithOptions(Options{
+		PkgDir: filepath.Join(root, "pkg"),
+		Namer: ChainNamers(func(ctx NameContext) string {
+			seen = append(seen, ctx)
+			return ""
+		}, PackagePrefixNamer, SuffixNamer(map[string]string{"Req": "Request", "q": "x"})),
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+
+	for _, want := range []string{
+		"export type OrderAdminStatus = \"open\";",
+		"export type OrderAdminLabels = Record<string, string>;",
+		"export interface OrderAdminListRequest {",
+		"readonly status: OrderAdminStatus;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	kinds := make(map[string]NameContext)
+	for _, ctx := range seen {
+		kinds[ctx.Name] = ctx
+	}
+	if kinds["Status"].Kind != KindEnum || kinds["Labels"].Kind != KindMap || kinds["ListReq"].Kind != KindStruct {
+		t.Fatalf("unexpected kinds: %+v", seen)
+	}
+	if ctx := kinds["Labels"]; ctx.Doc != "Labels are shown next to the order." || ctx.Package != "order/admin" ||
+		ctx.File != "order/admin/dto.go" || ctx.ImportPath != "example.com/test/pkg/order/admin" {
+		t.Fatalf("unexpected context: %+v", ctx)
+	}
+	if tag := kinds["ListReq"].Tags["Status"]; tag != `json:"status"` {
+		t.Fatalf("unexpected tag %q", tag)
+	}
+}
+
+func TestGenerateTypes_NamerEmptyKeepsGoName(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/order/dto.go", `package order
+
+type Status string
+
+const (
+	StatusOpen Status = "open"
+)
+
+type Labels map[string]string
+
+type ListReq struct {
+	Status Status `+"`json:\"status\"`"+`
+	Labels Labels `+"`json:\"labels\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	empty, err := GenerateTypesWithOptions(Options{
+		PkgDir: filepath.Join(root, "pkg"),
+		Namer:  func(NameContext) string { return "" },
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	same, err := GenerateTypesWithOptions(Options{
+		PkgDir: filepath.Join(root, "pkg"),
+		Namer:  func(ctx NameContext) string { return ctx.Name },
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	if empty != same {
+		t.Fatalf("expected an empty name to match ctx.Name:\n%s\n---\n%s", empty, same)
+	}
+	for _, want := range []string{
+		"export type Status = \"open\";",
+		"export type Labels = Record<string, string>;",
+		"export interface ListReq {",
+	} {
+		if !strings.Contains(empty, want) {
+			t.Fatalf("expected %q in output:\n%s", want, empty)
+		}
+	}
+}
+
+func TestLastSegmentNamer(t *testing.T) {
+	if got := LastSegmentNamer(NameContext{Name: "Item", Package: "order/line-items"}); got != "LineItemsItem" {
+		t.Fatalf("LastSegmentNamer = %q", got)
+	}
+	if got := LastSegmentNamer(NameContext{Name: "Item"}); got != "Item" {
+		t.Fatalf("LastSegmentNamer (root) = %q", got)
+	}
+}
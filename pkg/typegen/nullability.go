@@ -0,0 +1,189 @@
+package typegen
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+// NullPolicy is how a property that may be absent or empty is typed.
+type NullPolicy string
+
+const (
+	// NullUnion types the property T | null.
+	NullUnion NullPolicy = "null"
+	// NullOptional marks the property optional: name?: T.
+	NullOptional NullPolicy = "optional"
+	// NullUndefined types the property T | undefined.
+	NullUndefined NullPolicy = "undefined"
+	// NullRequired types the property T.
+	NullRequired NullPolicy = "required"
+)
+
+// Nullability chooses a NullPolicy per field category. Empty policies keep
+// the default: pointers are T | null, omitempty / omitzero fields
+// optional, slices and maps required.
+//
+// The type categories decide null and undefined; Omitempty decides the
+// optional marker and may add null or undefined on top, so a pointer field
+// with omitempty under {Pointer: "null", Omitempty: "optional"} is
+// name?: T | null.
+type Nullability struct {
+	Pointer   NullPolicy `json:"pointer" yaml:"pointer"`
+	Omitempty NullPolicy `json:"omitempty" yaml:"omitempty"`
+	Slice     NullPolicy `json:"slice" yaml:"slice"`
+	Map       NullPolicy `json:"map" yaml:"map"`
+}
+
+// ParseNullability parses "category=policy" pairs separated by commas, e.g.
+// "pointer=optional,slice=null".
+func ParseNullability(s string) (Nullability, error) {
+	var n Nullability
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		category, policy, ok := strings.Cut(pair, "=")
+		if !ok {
+			return n, fmt.Errorf("nullability %q: want category=policy", pair)
+		}
+		switch strings.TrimSpace(category) {
+		case "pointer":
+			n.Pointer = NullPolicy(strings.TrimSpace(policy))
+		case "omitempty":
+			n.Omitempty = NullPolicy(strings.TrimSpace(policy))
+		case "slice":
+			n.Slice = NullPolicy(strings.TrimSpace(policy))
+		case "map":
+			n.Map = NullPolicy(strings.TrimSpace(policy))
+		default:
+			return n, fmt.Errorf("unknown nullability category %q", category)
+		}
+	}
+	return n, n.validate()
+}
+
+func (n Nullability) validate() error {
+	for _, policy := range []NullPolicy{n.Pointer, n.Omitempty, n.Slice, n.Map} {
+		switch policy {
+		case "", NullUnion, NullOptional, NullUndefined, NullRequired:
+		default:
+			return fmt.Errorf("unknown nullability policy %q", policy)
+		}
+	}
+	return nil
+}
+
+// applyNullability retypes the properties of struct declarations that fall
+// in a category with a policy. omitempty is read from the json tag; the
+// types of omitempty properties before the policies are kept in
+// decl.omitempty for renameProperties.
+func applyNullability(decls []*declaration, scan *sourceScan, n Nullability) {
+	if n == (Nullability{}) {
+		return
+	}
+
+	props := make(map[string][]scannedProperty)
+	for _, st := range scan.types {
+		if len(st.properties) > 0 {
+			props[st.key] = st.properties
+		}
+	}
+
+	for _, decl := range decls {
+		iface, ok := decl.node.(*bindings.Interface)
+		if !ok {
+			continue
+		}
+		byName := make(map[string]scannedProperty, len(props[decl.key]))
+		for _, prop := range props[decl.key] {
+			byName[prop.name] = prop
+		}
+
+		for _, field := range iface.Fields {
+			prop, ok := byName[field.Name]
+			if !ok {
+				continue
+			}
+			omitempty := omitsEmpty(prop.tag, "json")
+			if omitempty && n.Omitempty != "" {
+				if decl.omitempty == nil {
+					decl.omitempty = make(map[string]bindings.ExpressionType)
+				}
+				decl.omitempty[field.Name] = cloneNode(field.Type).(bindings.ExpressionType)
+			}
+			n.applyField(field, prop, omitempty)
+		}
+	}
+}
+
+// applyField applies the policies of n to field, whose struct tag has
+// omitempty or omitzero when omitempty is set.
+func (n Nullability) applyField(field *bindings.PropertySignature, prop scannedProperty, omitempty bool) {
+	var category NullPolicy
+	switch {
+	case prop.pointer:
+		category = n.Pointer
+	case prop.kind == KindSlice:
+		category = n.Slice
+	case prop.kind == KindMap:
+		category = n.Map
+	}
+	if category == "" && (!omitempty || n.Omitempty == "") {
+		return
+	}
+
+	null := isNullable(field.Type)
+	undefined := false
+	if category != "" {
+		null = category == NullUnion
+		undefined = category == NullUndefined
+		field.QuestionToken = category == NullOptional
+	}
+	if omitempty && n.Omitempty != "" {
+		null = null || n.Omitempty == NullUnion
+		undefined = undefined || n.Omitempty == NullUndefined
+		field.QuestionToken = n.Omitempty == NullOptional
+	} else if omitempty {
+		field.QuestionToken = true
+	}
+	field.Type = withNullability(field.Type, null, undefined)
+}
+
+// omitsEmpty reports whether the tag key of a field has the omitempty or
+// omitzero option.
+func omitsEmpty(tag reflect.StructTag, key string) bool {
+	value, _ := tag.Lookup(key)
+	_, options, _ := strings.Cut(value, ",")
+	flags := strings.Split(options, ",")
+	return slices.Contains(flags, "omitempty") || slices.Contains(flags, "omitzero")
+}
+
+// withNullability returns typ without null and undefined, adding them back
+// as requested.
+func withNullability(typ bindings.ExpressionType, null, undefined bool) bindings.ExpressionType {
+	types := []bindings.ExpressionType{typ}
+	if union, ok := typ.(*bindings.UnionType); ok {
+		types = slices.DeleteFunc(slices.Clone(union.Types), func(t bindings.ExpressionType) bool {
+			if _, ok := t.(*bindings.Null); ok {
+				return true
+			}
+			kw, ok := t.(*bindings.LiteralKeyword)
+			return ok && *kw == bindings.KeywordUndefined
+		})
+	}
+	if null {
+		types = append(types, &bindings.Null{})
+	}
+	if undefined {
+		types = append(types, keyword(bindings.KeywordUndefined))
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	return bindings.Union(types...)
+}
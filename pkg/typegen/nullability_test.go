@@ -0,0 +1,63 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_Nullability(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/item/item.go", `package item
+
+import "example.com/test/pkg/tag"
+
+type Item struct {
+	Owner *string           `+"`json:\"owner\"`"+`
+	Note  *string           `+"`json:\"note,omitempty\"`"+`
+	Name  string            `+"`json:\"name,omitempty\"`"+`
+	Tags  tag.Tags          `+"`json:\"tags\"`"+`
+	Attrs map[string]string `+"`json:\"attrs\"`"+`
+}
+`)
+	writeFile(t, root, "pkg/tag/tag.go", "package tag\n\ntype Tags []string\n")
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{"readonly owner: string | null;", "readonly note?: string | null;", "readonly tags: Tags;"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected default %q in output:\n%s", want, output)
+		}
+	}
+
+	opts.Nullability, err = ParseNullability("pointer=optional, omitempty=undefined, slice=null, map=required")
+	if err != nil {
+		t.Fatalf("ParseNullability: %v", err)
+	}
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"readonly owner?: string;",
+		"readonly note: string | undefined;",
+		"readonly name: string | undefined;",
+		"readonly tags: Tags | null;",
+		"readonly attrs: Record<string, string>;",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	if _, err := ParseNullability("pointer=maybe"); err == nil {
+		t.Fatalf("expected unknown policy to fail")
+	}
+}
@@ -0,0 +1,95 @@
+package typegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+const openAPIVersion = "3.1.0"
+
+type openAPIDocument struct {
+	OpenAPI    string            `json:"openapi" yaml:"openapi"`
+	Info       openAPIInfo       `json:"info" yaml:"info"`
+	Components openAPIComponents `json:"components" yaml:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]any `json:"schemas" yaml:"schemas"`
+}
+
+// GenerateOpenAPIWithOptions generates an OpenAPI 3.1 document in YAML whose
+// components.schemas hold the declarations that GenerateTypesWithOptions would
+// emit, named exactly like the TypeScript output.
+func GenerateOpenAPIWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderOpenAPI(gen)
+}
+
+func renderOpenAPI(gen *generation) (string, error) {
+	doc, err := openAPIComponentsDocument(gen)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", fmt.Errorf("marshal openapi yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("marshal openapi yaml: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GenerateOpenAPIJSONWithOptions is GenerateOpenAPIWithOptions with JSON output.
+func GenerateOpenAPIJSONWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderOpenAPIJSON(gen)
+}
+
+func renderOpenAPIJSON(gen *generation) (string, error) {
+	doc, err := openAPIComponentsDocument(gen)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal openapi json: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+func openAPIComponentsDocument(gen *generation) (*openAPIDocument, error) {
+	// OpenAPI 3.1 schema objects are JSON Schema 2020-12, so only the ref
+	// location differs from the JSON Schema backend.
+	schemas, err := newSchemaWriter(gen, "#/components/schemas/").definitions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:   gen.pkgImportPath,
+			Version: "0.0.0",
+		},
+		Components: openAPIComponents{
+			Schemas: schemas,
+		},
+	}, nil
+}
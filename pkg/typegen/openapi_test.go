@@ -0,0 +1,58 @@
+package typegen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateOpenAPI_ComponentsUseTypeScriptNames(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type FooReq struct {
+	Bar Bar `+"`json:\"bar\"`"+`
+}
+
+type Bar struct {
+	Name string `+"`json:\"name\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateOpenAPIWithOptions(Options{
+		PkgDir: filepath.Join(root, "pkg"),
+		TypeNameMapper: func(typeName, moduleName string) string {
+			return "Api" + typeName
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateOpenAPIWithOptions: %v", err)
+	}
+
+	var doc struct {
+		OpenAPI    string `yaml:"openapi"`
+		Components struct {
+			Schemas map[string]map[string]any `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, output)
+	}
+
+	if doc.OpenAPI != openAPIVersion {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+	req, ok := doc.Components.Schemas["ApiFooReq"]
+	if !ok {
+		t.Fatalf("expected ApiFooReq schema:\n%s", output)
+	}
+	bar := req["properties"].(map[string]any)["bar"].(map[string]any)
+	if bar["$ref"] != "#/components/schemas/ApiBar" {
+		t.Fatalf("unexpected bar ref %v", bar["$ref"])
+	}
+}
@@ -0,0 +1,181 @@
+package typegen
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coder/guts"
+)
+
+// muxRoute is a pattern registered on a net/http ServeMux.
+type muxRoute struct {
+	// pattern is the registered pattern, with the whitespace after the
+	// method collapsed: "GET /users/{id}".
+	pattern string
+	// method is empty when the pattern matches every method, which the
+	// manifest writes as "*".
+	method string
+	host   string
+	path   string
+	// params are the wildcard names of path, in order.
+	params []string
+	// position is "<pkg>/<file>:<line>" of the registration.
+	position string
+}
+
+// muxRegistrations are the net/http functions and ServeMux methods whose
+// first argument is a pattern.
+var muxRegistrations = map[string]bool{
+	"net/http.Handle":                 true,
+	"net/http.HandleFunc":             true,
+	"(*net/http.ServeMux).Handle":     true,
+	"(*net/http.ServeMux).HandleFunc": true,
+}
+
+// scanMuxRoutes finds the registrations of constant patterns in the loaded
+// packages. Patterns built at run time are left out.
+func scanMuxRoutes(golang *guts.GoParser, pkgDir string, packages []packageInfo) ([]muxRoute, error) {
+	seen := make(map[string]bool)
+	var routes []muxRoute
+	for _, pkg := range packages {
+		loaded, ok := golang.Pkgs[pkg.importPath]
+		if !ok || loaded.TypesInfo == nil {
+			continue
+		}
+		for _, file := range loaded.Syntax {
+			var err error
+			ast.Inspect(file, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok || err != nil || len(call.Args) != 2 {
+					return err == nil
+				}
+				if !isMuxRegistration(loaded.TypesInfo, call.Fun) {
+					return true
+				}
+				value := loaded.TypesInfo.Types[call.Args[0]].Value
+				if value == nil || value.Kind() != constant.String {
+					return true
+				}
+				pos := loaded.Fset.Position(call.Pos())
+				position := pos.Filename
+				if rel, relErr := filepath.Rel(pkgDir, pos.Filename); relErr == nil {
+					position = filepath.ToSlash(rel)
+				}
+				position += ":" + strconv.Itoa(pos.Line)
+
+				route, parseErr := parseMuxPattern(constant.StringVal(value))
+				if parseErr != nil {
+					err = fmt.Errorf("%s: %w", position, parseErr)
+					return false
+				}
+				route.position = position
+				if !seen[route.pattern] {
+					seen[route.pattern] = true
+					routes = append(routes, route)
+				}
+				return true
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].path != routes[j].path {
+			return routes[i].path < routes[j].path
+		}
+		return routes[i].pattern < routes[j].pattern
+	})
+	return routes, nil
+}
+
+func isMuxRegistration(info *types.Info, fun ast.Expr) bool {
+	var ident *ast.Ident
+	switch fun := fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return false
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	return ok && muxRegistrations[fn.FullName()]
+}
+
+// parseMuxPattern splits a ServeMux pattern, "[METHOD ][HOST]/[PATH]".
+func parseMuxPattern(pattern string) (muxRoute, error) {
+	var route muxRoute
+	rest := pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 && !strings.Contains(pattern[:i], "/") {
+		route.method = pattern[:i]
+		rest = strings.TrimLeft(pattern[i:], " \t")
+	}
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return muxRoute{}, fmt.Errorf("invalid ServeMux pattern %q: missing /", pattern)
+	}
+	route.host, route.path = rest[:slash], rest[slash:]
+	route.params = pathParams(route.path)
+	route.pattern = rest
+	if route.method != "" {
+		route.pattern = route.method + " " + rest
+	}
+	return route, nil
+}
+
+const routesHelpers = `
+export type Route = keyof typeof routes;
+
+export type RouteParams<R extends Route> = {
+    readonly [K in (typeof routes)[R]["params"][number]]: string;
+};
+`
+
+// GenerateRoutesWithOptions generates a manifest of the patterns registered
+// with http.Handle, http.HandleFunc and the ServeMux methods of the same
+// name in the scanned packages.
+func GenerateRoutesWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderRoutes(gen)
+}
+
+func renderRoutes(gen *generation) (string, error) {
+	routes, err := gen.muxRoutes()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by typegen. DO NOT EDIT.\n\n")
+	b.WriteString("export const routes = {\n")
+	for _, route := range routes {
+		params := make([]string, len(route.params))
+		for i, param := range route.params {
+			params[i] = strconv.Quote(param)
+		}
+		fmt.Fprintf(&b, "    // From %s\n", route.position)
+		fmt.Fprintf(&b, "    %s: {\n", strconv.Quote(route.pattern))
+		fmt.Fprintf(&b, "        method: %s,\n", strconv.Quote(cmp.Or(route.method, "*")))
+		if route.host != "" {
+			fmt.Fprintf(&b, "        host: %s,\n", strconv.Quote(route.host))
+		}
+		fmt.Fprintf(&b, "        path: %s,\n", strconv.Quote(route.path))
+		fmt.Fprintf(&b, "        params: [%s],\n", strings.Join(params, ", "))
+		b.WriteString("    },\n")
+	}
+	b.WriteString("} as const;\n")
+	b.WriteString(routesHelpers)
+	return b.String(), nil
+}
@@ -0,0 +1,94 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRoutes_ServeMuxPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/api/routes.go", `package api
+
+import "net/http"
+
+const usersPath = "/users"
+
+func Register(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc("GET /users/{id}", getUser)
+	mux.HandleFunc("POST "+usersPath, createUser)
+	mux.Handle("api.example.com/files/{path...}", http.NotFoundHandler())
+	mux.HandleFunc(prefix+"/dynamic", getUser)
+	http.HandleFunc("GET /{$}", getUser)
+}
+
+func getUser(w http.ResponseWriter, r *http.Request)    {}
+func createUser(w http.ResponseWriter, r *http.Request) {}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateRoutesWithOptions(Options{PkgDir: filepath.Join(root, "pkg")})
+	if err != nil {
+		t.Fatalf("GenerateRoutesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"    // From api/routes.go:12\n    \"GET /{$}\": {\n        method: \"GET\",\n        path: \"/{$}\",\n        params: [],\n    },\n",
+		"    \"api.example.com/files/{path...}\": {\n        method: \"*\",\n        host: \"api.example.com\",\n        path: \"/files/{path...}\",\n        params: [\"path\"],\n    },\n",
+		"    \"POST /users\": {\n        method: \"POST\",\n        path: \"/users\",\n        params: [],\n    },\n",
+		"    \"GET /users/{id}\": {\n        method: \"GET\",\n        path: \"/users/{id}\",\n        params: [\"id\"],\n    },\n",
+		"} as const;\n",
+		"export type RouteParams<R extends Route> = {",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "dynamic") {
+		t.Fatalf("expected patterns built at run time to be left out:\n%s", output)
+	}
+}
+
+func TestGenerateTypes_IgnoresInvalidMuxPatterns(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/api/routes.go", `package api
+
+import "net/http"
+
+type Status struct {
+	OK bool
+}
+
+func Register(mux *http.ServeMux) {
+	mux.HandleFunc("status", func(w http.ResponseWriter, r *http.Request) {})
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg")}
+	if _, err := GenerateTypesWithOptions(opts); err != nil {
+		t.Fatalf("expected mux patterns to be left alone without FormatRoutes, got %v", err)
+	}
+	_, err := GenerateRoutesWithOptions(opts)
+	if err == nil || !strings.Contains(err.Error(), "missing /") {
+		t.Fatalf("expected invalid pattern error, got %v", err)
+	}
+}
+
+func TestParseMuxPattern(t *testing.T) {
+	route, err := parseMuxPattern("DELETE\t /orders/{id}/items/{item}")
+	if err != nil {
+		t.Fatalf("parseMuxPattern: %v", err)
+	}
+	if route.pattern != "DELETE /orders/{id}/items/{item}" || route.method != "DELETE" || strings.Join(route.params, ",") != "id,item" {
+		t.Fatalf("unexpected route %+v", route)
+	}
+	if _, err := parseMuxPattern("GET users"); err == nil {
+		t.Fatalf("expected an error for a pattern without a path")
+	}
+}
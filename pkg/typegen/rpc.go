@@ -0,0 +1,221 @@
+package typegen
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+)
+
+// directiveRPC in the doc comment of an interface emits it as an RPC
+// contract.
+const directiveRPC = "typegen:rpc"
+
+// RPC contracts are declarations with rpc set, whose node is an interface
+// with one property per method. The type of a method property is the
+// intersection of a type literal holding the parameters and the result
+// type: void, the single result, or an ArrayLiteralType of several. Keeping
+// them in bindings nodes lets renames, deduplication and the dependency
+// closure treat them like any other declaration; serializeService prints
+// them as method signatures.
+
+// buildServices declares the interfaces marked with the rpc directive or
+// listed in services (as "<import path>.<Name>") as RPC contracts.
+func buildServices(golang *guts.GoParser, mapper *typeMapper, decls []*declaration, scan *sourceScan, services []string) ([]*declaration, error) {
+	listed := make(map[string]bool, len(services))
+	for _, service := range services {
+		listed[service] = true
+	}
+
+	added := false
+	for _, st := range scan.interfaceTypes {
+		if !st.hasDirective(directiveRPC) && !listed[st.importPath+"."+st.name] {
+			continue
+		}
+		if _, ok := scan.interfaces[st.key]; !ok {
+			return nil, fmt.Errorf("rpc %s (%s): already emitted as a union", st.name, scan.positions[st.key])
+		}
+		pkg, ok := golang.Pkgs[st.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		obj := pkg.Types.Scope().Lookup(st.name)
+		if obj == nil {
+			continue
+		}
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+
+		// go/types sorts methods by name; keep them in source order.
+		funcs := slices.Collect(iface.Methods())
+		sort.Slice(funcs, func(i, j int) bool {
+			return funcs[i].Pos() < funcs[j].Pos()
+		})
+
+		var methods []*bindings.PropertySignature
+		for _, method := range funcs {
+			if !method.Exported() {
+				continue
+			}
+			signature, err := rpcSignature(mapper, method.Signature())
+			if err != nil {
+				return nil, fmt.Errorf("rpc %s.%s (%s): %w", st.name, method.Name(), scan.positions[st.key], err)
+			}
+			methods = append(methods, &bindings.PropertySignature{Name: method.Name(), Type: signature})
+		}
+
+		node := &bindings.Interface{
+			Name:      bindings.Identifier{Name: st.name, Package: pkg.Types, Prefix: strings.TrimSuffix(st.key, st.name)},
+			Modifiers: []bindings.Modifier{bindings.ModifierExport},
+			Fields:    methods,
+			Source:    bindings.Source{File: st.file},
+		}
+		if st.doc != "" {
+			node.AppendComment(bindings.SyntheticComment{Leading: true, Text: st.doc})
+		}
+		decls = append(decls, &declaration{
+			key:    st.key,
+			name:   st.key,
+			node:   node,
+			source: st.file,
+			refs:   referencedKeys(node),
+			rpc:    true,
+		})
+		delete(scan.interfaces, st.key)
+		st.kind = KindService
+		scan.types = append(scan.types, st)
+		added = true
+	}
+
+	if added {
+		sort.Slice(decls, func(i, j int) bool {
+			return decls[i].key < decls[j].key
+		})
+	}
+	return decls, nil
+}
+
+// rpcSignature encodes a method signature, leaving out context.Context
+// parameters and error results: a failed call rejects the promise.
+func rpcSignature(mapper *typeMapper, sig *types.Signature) (bindings.ExpressionType, error) {
+	params := &bindings.TypeLiteralNode{}
+	for i := range sig.Params().Len() {
+		param := sig.Params().At(i)
+		if isContext(param.Type()) {
+			continue
+		}
+		typ, err := mapper.expression(param.Type())
+		if err != nil {
+			return nil, err
+		}
+		name := param.Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		params.Members = append(params.Members, &bindings.PropertySignature{Name: name, Type: typ})
+	}
+
+	var results []bindings.ExpressionType
+	for i := range sig.Results().Len() {
+		result := sig.Results().At(i).Type()
+		if types.Identical(result, types.Universe.Lookup("error").Type()) {
+			continue
+		}
+		typ, err := mapper.expression(result)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, typ)
+	}
+
+	var result bindings.ExpressionType
+	switch len(results) {
+	case 0:
+		result = keyword(bindings.KeywordVoid)
+	case 1:
+		result = results[0]
+	default:
+		result = &bindings.ArrayLiteralType{Elements: results}
+	}
+	return &bindings.TypeIntersection{Types: []bindings.ExpressionType{params, result}}, nil
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// serializeService prints an RPC contract with async method signatures.
+func serializeService(vm *bindings.Bindings, decl *declaration) (string, error) {
+	iface := decl.node.(*bindings.Interface)
+
+	var b strings.Builder
+	if decl.source != "" {
+		fmt.Fprintf(&b, "// From %s\n", decl.source)
+	}
+	for _, comment := range iface.Comments() {
+		b.WriteString("/**\n")
+		for _, line := range strings.Split(strings.TrimSpace(comment.Text), "\n") {
+			b.WriteString(strings.TrimRight(" * "+line, " ") + "\n")
+		}
+		b.WriteString(" */\n")
+	}
+	fmt.Fprintf(&b, "export interface %s {\n", decl.name)
+	for _, method := range iface.Fields {
+		signature := method.Type.(*bindings.TypeIntersection)
+		params := signature.Types[0].(*bindings.TypeLiteralNode)
+
+		args := make([]string, 0, len(params.Members))
+		for _, param := range params.Members {
+			typ, err := serializeExpression(vm, param.Type)
+			if err != nil {
+				return "", fmt.Errorf("%s parameter %s: %w", method.Name, param.Name, err)
+			}
+			args = append(args, param.Name+": "+typ)
+		}
+
+		var result string
+		if tuple, ok := signature.Types[1].(*bindings.ArrayLiteralType); ok {
+			elems := make([]string, 0, len(tuple.Elements))
+			for _, elem := range tuple.Elements {
+				typ, err := serializeExpression(vm, elem)
+				if err != nil {
+					return "", fmt.Errorf("%s result: %w", method.Name, err)
+				}
+				elems = append(elems, typ)
+			}
+			result = "[" + strings.Join(elems, ", ") + "]"
+		} else {
+			typ, err := serializeExpression(vm, signature.Types[1])
+			if err != nil {
+				return "", fmt.Errorf("%s result: %w", method.Name, err)
+			}
+			result = typ
+		}
+		fmt.Fprintf(&b, "    %s(%s): Promise<%s>;\n", method.Name, strings.Join(args, ", "), result)
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// serializeExpression prints a type expression by serializing it as the
+// right-hand side of a type alias.
+func serializeExpression(vm *bindings.Bindings, expr bindings.ExpressionType) (string, error) {
+	obj, err := vm.ToTypescriptNode(&bindings.Alias{Name: bindings.Identifier{Name: "T"}, Type: expr})
+	if err != nil {
+		return "", err
+	}
+	text, err := vm.SerializeToTypescript(obj)
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "type T = ")
+	return strings.TrimSuffix(text, ";"), nil
+}
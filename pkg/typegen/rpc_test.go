@@ -0,0 +1,91 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_RPCContracts(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/user/user.go", `package user
+
+import "context"
+
+type GetUserRequest struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+type User struct {
+	ID   string  `+"`json:\"id\"`"+`
+	Boss *User   `+"`json:\"boss\"`"+`
+	Tags []Tag   `+"`json:\"tags\"`"+`
+}
+
+type Tag struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type Unused struct{}
+
+// UserService manages users.
+//
+//typegen:rpc
+type UserService interface {
+	GetUser(ctx context.Context, req GetUserRequest) (*User, error)
+	Delete(ctx context.Context, id string) error
+	Count(context.Context, []string) (int, bool, error)
+	internal()
+}
+
+type Store interface {
+	Get(id string) User
+}
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true, IncludeType: "^UserService$"}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"/**\n * UserService manages users.\n */\nexport interface UserService {\n",
+		"    GetUser(req: GetUserRequest): Promise<User | null>;\n",
+		"    Delete(id: string): Promise<void>;\n",
+		"    Count(arg1: string[]): Promise<[number, boolean]>;\n",
+		"export interface GetUserRequest {",
+		"export interface Tag {",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if get, del, count := strings.Index(output, "GetUser("), strings.Index(output, "Delete("), strings.Index(output, "Count("); get > del || del > count {
+		t.Fatalf("expected methods in source order:\n%s", output)
+	}
+	if strings.Contains(output, "internal") || strings.Contains(output, "Unused") || strings.Contains(output, "Store") {
+		t.Fatalf("expected unexported methods, unreferenced types and unmarked interfaces to be left out:\n%s", output)
+	}
+
+	opts.Services = []string{"example.com/test/pkg/user.Store"}
+	opts.IncludeType = "^Store$"
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (option): %v", err)
+	}
+	if !strings.Contains(output, "Get(id: string): Promise<User>;") {
+		t.Fatalf("expected Store contract:\n%s", output)
+	}
+
+	schemas, err := GenerateZodWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateZodWithOptions: %v", err)
+	}
+	if strings.Contains(schemas, "StoreSchema") || !strings.Contains(schemas, "UserSchema") {
+		t.Fatalf("expected schemas for the DTOs only:\n%s", schemas)
+	}
+}
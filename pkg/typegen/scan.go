@@ -0,0 +1,696 @@
+package typegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+// sourceScan is what a single syntax-only pass over the Go files under
+// PkgDir finds: exported interfaces, which guts cannot express, the other
+// exported types for the rename map, and the ts struct tags of their fields.
+type sourceScan struct {
+	// interfaces holds the keys of exported interface types.
+	interfaces map[string]struct{}
+	// interfaceTypes describes those interfaces, for discriminated unions.
+	interfaceTypes []scannedType
+	// types are the exported non-interface types, in walk order.
+	types []scannedType
+	// positions maps the key of every exported type to "<pkg>/<file>:<line>".
+	positions map[string]string
+	// routes are the functions carrying the route directive, in walk order.
+	routes []scannedRoute
+	// constants are the constants carrying the const directive, in walk
+	// order.
+	constants []scannedConst
+}
+
+type scannedType struct {
+	// key is the guts node key: the Go name with its package prefix.
+	key  string
+	name string
+	kind DeclarationKind
+	// importPath is the import path of the declaring package, and rel that
+	// path relative to PkgDir ("" for PkgDir itself).
+	importPath string
+	rel        string
+	// moduleName is the first directory below PkgDir, or the base of the
+	// import path for PkgDir itself.
+	moduleName string
+	// file is "<pkg>/<file>", as printed in the "From" header.
+	file string
+	doc  string
+	// directives are the //typegen: comment lines of the doc comment,
+	// without the comment marker, e.g. "typegen:brand".
+	directives []string
+	// tags maps Go field names of structs to their raw struct tags.
+	tags map[string]string
+	// fields maps TypeScript property names to their ts tag overrides.
+	fields map[string]fieldOverride
+	// properties are the fields of a struct that guts emits as properties.
+	properties []scannedProperty
+	// goType is the type expression of the declaration.
+	goType ast.Expr
+}
+
+// scannedProperty is a struct field as guts names it.
+type scannedProperty struct {
+	// name is the property name guts emits: the json name, or the Go name.
+	name   string
+	goName string
+	// jsonTagged reports whether name comes from a json tag.
+	jsonTagged bool
+	// hidden marks a json:"-" field, which only another field tag can
+	// name. Until renameProperties drops or renames it, its property is
+	// named hiddenPrefix + goName.
+	hidden bool
+	tag    reflect.StructTag
+	// pointer reports whether the Go type is a pointer, and kind is
+	// KindSlice or KindMap for slices and maps, including defined ones.
+	pointer bool
+	kind    DeclarationKind
+	// typeKey is the key of the named type of the field, resolved to kind
+	// once every package is scanned.
+	typeKey string
+	// goType is the type expression of the field.
+	goType ast.Expr
+}
+
+// fieldOverride is a parsed ts struct tag.
+type fieldOverride struct {
+	// omit drops the property (ts:"-").
+	omit bool
+	// typ replaces the generated type when set (ts:"type=...").
+	typ      bindings.ExpressionType
+	optional *bool
+	readonly *bool
+}
+
+func scanSources(pkgDir, pkgImportPath string) (*sourceScan, error) {
+	scan := &sourceScan{
+		interfaces: make(map[string]struct{}),
+		positions:  make(map[string]string),
+	}
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(pkgDir, func(dir string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		name := entry.Name()
+		if dir != pkgDir && (name == "typegen" || strings.HasPrefix(name, ".")) {
+			return filepath.SkipDir
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		var goFiles []string
+		for _, file := range entries {
+			if file.IsDir() {
+				continue
+			}
+			fileName := file.Name()
+			if strings.HasSuffix(fileName, ".go") && !strings.HasSuffix(fileName, "_test.go") {
+				goFiles = append(goFiles, filepath.Join(dir, fileName))
+			}
+		}
+		if len(goFiles) == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(pkgDir, dir)
+		if err != nil {
+			return err
+		}
+
+		importPath := pkgImportPath
+		moduleName := path.Base(pkgImportPath)
+		pkgRel := ""
+		if rel != "." {
+			pkgRel = filepath.ToSlash(rel)
+			importPath = path.Join(pkgImportPath, pkgRel)
+			parts := strings.Split(filepath.ToSlash(rel), "/")
+			if len(parts) > 0 {
+				moduleName = parts[0]
+			}
+		}
+		prefix := prefixForImportPath(pkgImportPath, importPath)
+		// Defined types with constants are enums; constants may live in any
+		// file of the package.
+		first := len(scan.types)
+		constTypes := make(map[string]struct{})
+
+		for _, filePath := range goFiles {
+			parsed, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution|parser.ParseComments)
+			if err != nil {
+				return fmt.Errorf("parse file %s: %w", filePath, err)
+			}
+			relFile, err := filepath.Rel(pkgDir, filePath)
+			if err != nil {
+				return err
+			}
+			typeKey := fileTypeKeys(parsed, prefix, pkgImportPath)
+
+			for _, decl := range parsed.Decls {
+				if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+					if route, ok := scanRoute(funcDecl); ok {
+						route.importPath = importPath
+						route.position = fmt.Sprintf("%s:%d", filepath.ToSlash(relFile), fset.Position(funcDecl.Pos()).Line)
+						scan.routes = append(scan.routes, route)
+					}
+					continue
+				}
+				genDecl, ok := decl.(*ast.GenDecl)
+				if ok && genDecl.Tok == token.CONST {
+					for _, spec := range genDecl.Specs {
+						if value, ok := spec.(*ast.ValueSpec); ok {
+							if ident, ok := value.Type.(*ast.Ident); ok {
+								constTypes[ident.Name] = struct{}{}
+							}
+							for _, c := range scanConsts(genDecl, value) {
+								c.importPath = importPath
+								c.position = fmt.Sprintf("%s:%d", filepath.ToSlash(relFile), fset.Position(value.Pos()).Line)
+								scan.constants = append(scan.constants, c)
+							}
+						}
+					}
+					continue
+				}
+				if !ok || genDecl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range genDecl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					name := typeSpec.Name.Name
+					if name == "" || !ast.IsExported(name) {
+						continue
+					}
+					scan.positions[prefix+name] = fmt.Sprintf("%s:%d", filepath.ToSlash(relFile), fset.Position(typeSpec.Pos()).Line)
+					st := scannedType{
+						key:        prefix + name,
+						name:       name,
+						kind:       declarationKind(typeSpec.Type),
+						importPath: importPath,
+						rel:        pkgRel,
+						moduleName: moduleName,
+						file:       filepath.ToSlash(relFile),
+						doc:        typeDoc(genDecl, typeSpec),
+						directives: typeDirectives(genDecl, typeSpec),
+						goType:     typeSpec.Type,
+					}
+					if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+						scan.interfaces[st.key] = struct{}{}
+						scan.interfaceTypes = append(scan.interfaceTypes, st)
+						continue
+					}
+					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+						st.tags = structTags(structType)
+						st.properties = scanProperties(structType, typeKey)
+						st.fields, err = scanFieldOverrides(structType)
+						if err != nil {
+							return fmt.Errorf("%s: %s.%w", fset.Position(typeSpec.Pos()), name, err)
+						}
+					}
+					scan.types = append(scan.types, st)
+				}
+			}
+		}
+
+		for i := first; i < len(scan.types); i++ {
+			if _, ok := constTypes[scan.types[i].name]; ok && scan.types[i].kind == KindAlias {
+				scan.types[i].kind = KindEnum
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk pkg dir: %w", err)
+	}
+
+	kinds := make(map[string]DeclarationKind, len(scan.types))
+	for _, st := range scan.types {
+		kinds[st.key] = st.kind
+	}
+	for _, st := range scan.types {
+		for i, prop := range st.properties {
+			if prop.typeKey != "" {
+				st.properties[i].kind = kinds[prop.typeKey]
+			}
+		}
+	}
+
+	return scan, nil
+}
+
+// declarationKind classifies a type declaration by its Go type expression.
+// Enums are recognized later, from their constants.
+func declarationKind(expr ast.Expr) DeclarationKind {
+	switch expr.(type) {
+	case *ast.StructType:
+		return KindStruct
+	case *ast.MapType:
+		return KindMap
+	case *ast.ArrayType:
+		return KindSlice
+	default:
+		return KindAlias
+	}
+}
+
+func typeDoc(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) string {
+	doc := typeSpec.Doc
+	if doc == nil && len(genDecl.Specs) == 1 {
+		doc = genDecl.Doc
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// typeDirectives returns the //typegen: lines of the doc comment, which
+// typeDoc leaves out like every other directive.
+func typeDirectives(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) []string {
+	doc := typeSpec.Doc
+	if doc == nil && len(genDecl.Specs) == 1 {
+		doc = genDecl.Doc
+	}
+	if doc == nil {
+		return nil
+	}
+	var directives []string
+	for _, comment := range doc.List {
+		if directive, ok := strings.CutPrefix(comment.Text, "//typegen:"); ok {
+			directives = append(directives, "typegen:"+strings.TrimSpace(directive))
+		}
+	}
+	return directives
+}
+
+// hasDirective reports whether st carries the directive name, e.g.
+// "typegen:brand".
+func (st scannedType) hasDirective(name string) bool {
+	for _, directive := range st.directives {
+		if directive == name || strings.HasPrefix(directive, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripDirectiveComments removes the //typegen: lines guts copies into the
+// doc comments of declarations.
+func stripDirectiveComments(decls []*declaration) {
+	for _, decl := range decls {
+		var support *bindings.SupportComments
+		switch node := decl.node.(type) {
+		case *bindings.Interface:
+			support = &node.SupportComments
+		case *bindings.Alias:
+			support = &node.SupportComments
+		default:
+			continue
+		}
+
+		var comments []bindings.SyntheticComment
+		changed := false
+		for _, comment := range support.Comments() {
+			lines := strings.Split(comment.Text, "\n")
+			kept := lines[:0]
+			for _, line := range lines {
+				if strings.HasPrefix(strings.TrimLeft(line, " /*"), "typegen:") {
+					changed = true
+					continue
+				}
+				kept = append(kept, line)
+			}
+			comment.Text = strings.TrimRight(strings.Join(kept, "\n"), " \n")
+			if strings.TrimSpace(comment.Text) != "" {
+				comments = append(comments, comment)
+			}
+		}
+		if changed {
+			*support = bindings.SupportComments{}
+			support.AppendComments(comments)
+		}
+	}
+}
+
+// structTags returns the raw tags of the named fields of st.
+func structTags(st *ast.StructType) map[string]string {
+	var tags map[string]string
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		for _, name := range field.Names {
+			tags[name.Name] = raw
+		}
+		if len(field.Names) == 0 {
+			tags[embeddedName(field.Type)] = raw
+		}
+	}
+	return tags
+}
+
+// scanProperties lists the fields of st that guts emits as properties,
+// following its rules: unexported fields and embedded fields without a json
+// name are left out. json:"-" fields are listed as hidden, see
+// addHiddenProperties.
+func scanProperties(st *ast.StructType, typeKey func(ast.Expr) string) []scannedProperty {
+	var props []scannedProperty
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			if raw, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(raw)
+			}
+		}
+		jsonTag, hasJSON := tag.Lookup("json")
+		if jsonTag == "-" {
+			for _, name := range field.Names {
+				if name.IsExported() {
+					prop := scannedProperty{name: hiddenPrefix + name.Name, goName: name.Name, tag: tag, hidden: true}
+					prop.pointer, prop.kind, prop.typeKey = fieldKind(field.Type, typeKey)
+					prop.goType = field.Type
+					props = append(props, prop)
+				}
+			}
+			continue
+		}
+		jsonName, _, _ := strings.Cut(jsonTag, ",")
+
+		names := field.Names
+		if len(names) == 0 {
+			if !hasJSON {
+				continue
+			}
+			names = []*ast.Ident{ast.NewIdent(embeddedName(field.Type))}
+		}
+		for _, name := range names {
+			if !name.IsExported() {
+				continue
+			}
+			prop := scannedProperty{name: name.Name, goName: name.Name, tag: tag}
+			prop.pointer, prop.kind, prop.typeKey = fieldKind(field.Type, typeKey)
+			prop.goType = field.Type
+			if jsonName != "" {
+				prop.name = jsonName
+				prop.jsonTagged = true
+			}
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// fieldKind classifies the Go type of a field for Nullability.
+func fieldKind(expr ast.Expr, typeKey func(ast.Expr) string) (pointer bool, kind DeclarationKind, key string) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return true, "", ""
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return false, KindSlice, ""
+		}
+	case *ast.MapType:
+		return false, KindMap, ""
+	case *ast.Ident, *ast.SelectorExpr:
+		return false, "", typeKey(expr)
+	}
+	return false, "", ""
+}
+
+// fileTypeKeys returns a function giving the key of the scanned type a
+// type name of file refers to, or "" for types outside PkgDir.
+func fileTypeKeys(file *ast.File, prefix, pkgImportPath string) func(ast.Expr) string {
+	imports := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+	return func(expr ast.Expr) string {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return prefix + e.Name
+		case *ast.SelectorExpr:
+			pkg, ok := e.X.(*ast.Ident)
+			if !ok {
+				return ""
+			}
+			importPath, ok := imports[pkg.Name]
+			if !ok || (importPath != pkgImportPath && !strings.HasPrefix(importPath, pkgImportPath+"/")) {
+				return ""
+			}
+			return prefixForImportPath(pkgImportPath, importPath) + e.Sel.Name
+		}
+		return ""
+	}
+}
+
+// embeddedName is the field name of an embedded field of type expr.
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// scanFieldOverrides parses the ts tags of st, keyed by the property name
+// guts gives each field.
+func scanFieldOverrides(st *ast.StructType) (map[string]fieldOverride, error) {
+	var fields map[string]fieldOverride
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag := reflect.StructTag(raw)
+		value, ok := tag.Lookup("ts")
+		if !ok {
+			continue
+		}
+		override, err := parseFieldTag(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: ts tag: %w", fieldLabel(field), err)
+		}
+
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if len(field.Names) == 0 && jsonName == "" {
+			// Embedded without a json name: guts emits it as an extends
+			// clause, which has no property to override.
+			return nil, fmt.Errorf("%s: ts tag on an embedded field requires a json name", fieldLabel(field))
+		}
+		if fields == nil {
+			fields = make(map[string]fieldOverride)
+		}
+		if jsonName != "" && jsonName != "-" {
+			fields[jsonName] = override
+			continue
+		}
+		prefix := ""
+		if tag.Get("json") == "-" {
+			prefix = hiddenPrefix
+		}
+		for _, name := range field.Names {
+			if name.IsExported() {
+				fields[prefix+name.Name] = override
+			}
+		}
+	}
+	return fields, nil
+}
+
+func fieldLabel(field *ast.Field) string {
+	if len(field.Names) == 0 {
+		return "(embedded)"
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseFieldTag parses a ts tag value: "-", or a comma-separated list of
+// type=<expr>, optional[=bool] and readonly[=bool]. Commas inside the type
+// expression, e.g. in Record<string, number>, do not split options.
+func parseFieldTag(value string) (fieldOverride, error) {
+	if value == "-" {
+		return fieldOverride{omit: true}, nil
+	}
+
+	var override fieldOverride
+	for _, option := range splitTagOptions(value) {
+		key, arg, hasArg := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "type":
+			if strings.TrimSpace(arg) == "" {
+				return fieldOverride{}, fmt.Errorf("type requires a value")
+			}
+			typ, err := parseTypeExpression(strings.TrimSpace(arg))
+			if err != nil {
+				return fieldOverride{}, err
+			}
+			override.typ = typ
+		case "optional", "readonly":
+			flag := true
+			if hasArg {
+				parsed, err := strconv.ParseBool(arg)
+				if err != nil {
+					return fieldOverride{}, fmt.Errorf("%s: %w", key, err)
+				}
+				flag = parsed
+			}
+			if key == "optional" {
+				override.optional = &flag
+			} else {
+				override.readonly = &flag
+			}
+		case "":
+			return fieldOverride{}, fmt.Errorf("empty option in %q", value)
+		default:
+			return fieldOverride{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return override, nil
+}
+
+// splitTagOptions splits value on commas outside of brackets and quotes.
+func splitTagOptions(value string) []string {
+	var options []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '<' || c == '(' || c == '[' || c == '{':
+			depth++
+		case c == '>' || c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			options = append(options, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(options, value[start:])
+}
+
+// applyFieldOverrides rewrites the properties of the interfaces in decls
+// according to the ts tags found by scan. It runs after the guts mutations
+// so that readonly=false also undoes config.ReadOnly.
+func applyFieldOverrides(decls []*declaration, scan *sourceScan) {
+	overrides := make(map[string]map[string]fieldOverride)
+	for _, st := range scan.types {
+		if len(st.fields) > 0 {
+			overrides[st.key] = st.fields
+		}
+	}
+	if len(overrides) == 0 {
+		return
+	}
+
+	for _, decl := range decls {
+		fields, ok := overrides[decl.key]
+		if !ok {
+			continue
+		}
+		iface, ok := decl.node.(*bindings.Interface)
+		if !ok {
+			continue
+		}
+
+		kept := iface.Fields[:0]
+		for _, prop := range iface.Fields {
+			override, ok := fields[prop.Name]
+			if !ok {
+				kept = append(kept, prop)
+				continue
+			}
+			if override.omit {
+				continue
+			}
+			applyFieldOverride(prop, override)
+			kept = append(kept, prop)
+		}
+		iface.Fields = kept
+		decl.refs = referencedKeys(decl.node)
+	}
+}
+
+func applyFieldOverride(prop *bindings.PropertySignature, override fieldOverride) {
+	readonly := slices.Contains(prop.Modifiers, bindings.ModifierReadonly)
+	if override.typ != nil {
+		prop.Type = cloneNode(override.typ).(bindings.ExpressionType)
+	}
+	if override.optional != nil {
+		prop.QuestionToken = *override.optional
+	}
+	if override.readonly != nil && *override.readonly != readonly {
+		if *override.readonly {
+			prop.Modifiers = append(prop.Modifiers, bindings.ModifierReadonly)
+		} else {
+			prop.Modifiers = slices.DeleteFunc(prop.Modifiers, func(m bindings.Modifier) bool {
+				return m == bindings.ModifierReadonly
+			})
+			// config.ReadOnly also marks array types readonly.
+			if op, ok := prop.Type.(*bindings.OperatorNodeType); ok && op.Keyword == bindings.KeywordReadonly && override.typ == nil {
+				prop.Type = op.Type
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_FieldTagOverrides(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+import "encoding/json"
+
+type Money struct {
+	Cents int64
+}
+
+type Order struct {
+	ID      string          `+"`json:\"id\" ts:\"readonly=false\"`"+`
+	Total   Money           `+"`json:\"total\" ts:\"type=string\"`"+`
+	Note    string          `+"`json:\"note\" ts:\"optional\"`"+`
+	Meta    json.RawMessage `+"`json:\"meta\" ts:\"type=Record<string, unknown> | null,optional=true\"`"+`
+	Tags    []string        `+"`ts:\"readonly=false\"`"+`
+	Secret  string          `+"`json:\"secret\" ts:\"-\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateTypesWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		IncludeType: `^Order$`,
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+
+	for _, want := range []string{
+		"    id: string;",
+		"readonly total: string;",
+		"readonly note?: string;",
+		"readonly meta?: Record<string, unknown> | null;",
+		"    Tags: string[];",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "secret") {
+		t.Fatalf("did not expect omitted field in output:\n%s", output)
+	}
+	if strings.Contains(output, "interface Money") {
+		t.Fatalf("did not expect overridden reference to pull in Money:\n%s", output)
+	}
+}
+
+func TestParseFieldTag(t *testing.T) {
+	override, err := parseFieldTag("type=Record<string, number>, optional, readonly=false")
+	if err != nil {
+		t.Fatalf("parseFieldTag: %v", err)
+	}
+	if override.typ == nil || override.optional == nil || !*override.optional ||
+		override.readonly == nil || *override.readonly {
+		t.Fatalf("unexpected override: %+v", override)
+	}
+
+	for _, tag := range []string{"type=", "nullable", "optional=maybe", "type=string[", ","} {
+		if _, err := parseFieldTag(tag); err == nil {
+			t.Fatalf("expected error for %q", tag)
+		}
+	}
+}
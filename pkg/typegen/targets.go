@@ -0,0 +1,134 @@
+package typegen
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Target is one output produced by GenerateTargets. Its whitelist and rename
+// settings replace those of the base options, while PkgDir and PkgPath always
+// come from the base, so every target shares a single parse.
+type Target struct {
+	// Name identifies the target in errors. When empty, the output path is used.
+	Name           string
+	IncludePattern string
+	IncludeType    string
+	StripPrefix    bool
+	DisableRename  bool
+	FieldTag       string
+	FieldCasing    FieldCasing
+	// TypeNameMapper replaces the base mapper when set.
+	TypeNameMapper func(typeName string, moduleName string) string
+	// Namer replaces the base namer when set.
+	Namer  Namer
+	Output OutputOptions
+}
+
+// targetFor describes the single output of the non-target entry points.
+func targetFor(opts Options, output OutputOptions) Target {
+	return Target{
+		IncludePattern: opts.IncludePattern,
+		IncludeType:    opts.IncludeType,
+		StripPrefix:    opts.StripPrefix,
+		DisableRename:  opts.DisableRename,
+		FieldTag:       opts.FieldTag,
+		FieldCasing:    opts.FieldCasing,
+		Output:         output,
+	}
+}
+
+// options applies the target settings to base.
+func (t Target) options(base Options) Options {
+	opts := base
+	opts.IncludePattern = t.IncludePattern
+	opts.IncludeType = t.IncludeType
+	opts.StripPrefix = t.StripPrefix
+	opts.DisableRename = t.DisableRename
+	opts.FieldTag = t.FieldTag
+	opts.FieldCasing = t.FieldCasing
+	if t.TypeNameMapper != nil {
+		opts.TypeNameMapper = t.TypeNameMapper
+	}
+	if t.Namer != nil {
+		opts.Namer = t.Namer
+	}
+	return opts
+}
+
+func (t Target) label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	if t.Output.Stdout || t.Output.OutputPath == "-" {
+		return "stdout"
+	}
+	return resolveOutputPath(t.Output)
+}
+
+// checkTargets validates the output of every target before any is written.
+func checkTargets(base Options, targets []Target) error {
+	for _, t := range targets {
+		if err := checkBrandHelpers(t.options(base), t.Output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render produces the content of target from tree.
+func (tree *parsedTree) render(base Options, t Target) (string, error) {
+	if err := validateFormat(t.Output.Format); err != nil {
+		return "", err
+	}
+	gen, err := tree.generate(t.options(base))
+	if err != nil {
+		return "", err
+	}
+	return renderFormat(gen, t.Output.Format)
+}
+
+// GenerateTargets loads and converts the packages of base once and writes
+// every target from that single parse.
+func GenerateTargets(base Options, targets []Target) error {
+	if err := checkTargets(base, targets); err != nil {
+		return err
+	}
+	tree, err := parse(base)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		content, err := tree.render(base, t)
+		if err != nil {
+			return fmt.Errorf("target %s: %w", t.label(), err)
+		}
+		if err := writeOutput(content, t.Output); err != nil {
+			return fmt.Errorf("target %s: %w", t.label(), err)
+		}
+	}
+	return nil
+}
+
+// CheckTargets is Check for every target, sharing a single parse. Drifted
+// targets are reported together as *DriftError values joined with
+// errors.Join.
+func CheckTargets(base Options, targets []Target) error {
+	if err := checkTargets(base, targets); err != nil {
+		return err
+	}
+	tree, err := parse(base)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, t := range targets {
+		content, err := tree.render(base, t)
+		if err != nil {
+			return fmt.Errorf("target %s: %w", t.label(), err)
+		}
+		if err := checkContent(content, t.Output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package typegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTargets_IndependentWhitelistAndRename(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/admin/dto.go", `package admin
+
+type UserReq struct {
+	Name string `+"`json:\"name\"`"+`
+}
+`)
+	writeFile(t, root, "pkg/public/dto.go", `package public
+
+type Status string
+
+type ItemRes struct {
+	Status Status `+"`json:\"status\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	base := Options{PkgDir: filepath.Join(root, "pkg")}
+	adminPath := filepath.Join(root, "out", "admin.d.ts")
+	publicPath := filepath.Join(root, "out", "public.d.ts")
+	rawPath := filepath.Join(root, "out", "raw.d.ts")
+	err := GenerateTargets(base, []Target{
+		{Name: "admin", IncludePattern: `^admin/`, Output: OutputOptions{OutputPath: adminPath}},
+		{Name: "public", IncludePattern: `^public/`, StripPrefix: true, Output: OutputOptions{OutputPath: publicPath}},
+		{Name: "raw", IncludePattern: `^public/`, Output: OutputOptions{OutputPath: rawPath}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTargets: %v", err)
+	}
+
+	admin, err := os.ReadFile(adminPath)
+	if err != nil {
+		t.Fatalf("read admin: %v", err)
+	}
+	public, err := os.ReadFile(publicPath)
+	if err != nil {
+		t.Fatalf("read public: %v", err)
+	}
+
+	if !strings.Contains(string(admin), "export interface UserReq") || strings.Contains(string(admin), "ItemRes") {
+		t.Fatalf("unexpected admin output:\n%s", admin)
+	}
+	if !strings.Contains(string(public), "readonly status: Status;") || strings.Contains(string(public), "UserReq") {
+		t.Fatalf("unexpected public output:\n%s", public)
+	}
+
+	// Renames of one target must not leak into the shared parse.
+	raw, err := os.ReadFile(rawPath)
+	if err != nil {
+		t.Fatalf("read raw: %v", err)
+	}
+	if !strings.Contains(string(raw), "readonly status: public_Status;") {
+		t.Fatalf("expected prefixed alias without StripPrefix:\n%s", raw)
+	}
+
+	if err := CheckTargets(base, []Target{
+		{IncludePattern: `^admin/`, Output: OutputOptions{OutputPath: adminPath}},
+		{IncludePattern: `^public/`, Output: OutputOptions{OutputPath: publicPath}},
+	}); err == nil || !strings.Contains(err.Error(), "public.d.ts") || strings.Contains(err.Error(), "admin.d.ts") {
+		t.Fatalf("expected only public target to drift, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package typegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/coder/guts/bindings"
+)
+
+// parseTypeExpression parses the TypeScript type expressions accepted in
+// overrides: keywords, null, string/number/boolean literals, references with
+// optional type arguments, arrays (T[]), readonly arrays, parentheses and
+// unions. Referenced names are emitted verbatim.
+func parseTypeExpression(expr string) (bindings.ExpressionType, error) {
+	p := &typeParser{src: expr}
+	p.next()
+	t, err := p.union()
+	if err != nil {
+		return nil, fmt.Errorf("type %q: %w", expr, err)
+	}
+	if p.tok != "" {
+		return nil, fmt.Errorf("type %q: unexpected %q", expr, p.tok)
+	}
+	return t, nil
+}
+
+type typeParser struct {
+	src string
+	pos int
+	// tok is the current token; "" at the end of input.
+	tok string
+}
+
+func (p *typeParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+
+	start := p.pos
+	switch c := p.src[p.pos]; {
+	case c == '"' || c == '\'':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != c {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		p.pos++
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	case c == '_' || c == '$' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || p.src[p.pos] == '$' || p.src[p.pos] == '.' ||
+			unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.tok = p.src[start:min(p.pos, len(p.src))]
+}
+
+func (p *typeParser) expect(tok string) error {
+	if p.tok != tok {
+		return fmt.Errorf("expected %q, got %q", tok, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+func (p *typeParser) union() (bindings.ExpressionType, error) {
+	if p.tok == "|" {
+		p.next()
+	}
+	var types []bindings.ExpressionType
+	for {
+		t, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+		if p.tok != "|" {
+			break
+		}
+		p.next()
+	}
+	if len(types) == 1 {
+		return types[0], nil
+	}
+	return bindings.Union(types...), nil
+}
+
+func (p *typeParser) postfix() (bindings.ExpressionType, error) {
+	if p.tok == "readonly" {
+		p.next()
+		t, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return bindings.OperatorNode(bindings.KeywordReadonly, t), nil
+	}
+
+	t, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "[" {
+		p.next()
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		t = bindings.Array(t)
+	}
+	return t, nil
+}
+
+func (p *typeParser) primary() (bindings.ExpressionType, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of type")
+	case tok == "(":
+		p.next()
+		t, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		return t, p.expect(")")
+	case tok[0] == '"' || tok[0] == '\'':
+		p.next()
+		if len(tok) < 2 || tok[len(tok)-1] != tok[0] {
+			return nil, fmt.Errorf("unterminated string literal %s", tok)
+		}
+		quoted := tok
+		if tok[0] == '\'' {
+			quoted = `"` + strings.ReplaceAll(tok[1:len(tok)-1], `"`, `\"`) + `"`
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("string literal %s: %w", tok, err)
+		}
+		return &bindings.LiteralType{Value: value}, nil
+	case tok[0] == '-' || (tok[0] >= '0' && tok[0] <= '9'):
+		p.next()
+		if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			return &bindings.LiteralType{Value: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("number literal %s: %w", tok, err)
+		}
+		return &bindings.LiteralType{Value: f}, nil
+	}
+
+	if keyword, ok := typeKeywords[tok]; ok {
+		p.next()
+		k := keyword
+		return &k, nil
+	}
+	switch tok {
+	case "null":
+		p.next()
+		return &bindings.Null{}, nil
+	case "true", "false":
+		p.next()
+		return &bindings.LiteralType{Value: tok == "true"}, nil
+	}
+
+	if tok[0] != '_' && tok[0] != '$' && !unicode.IsLetter(rune(tok[0])) {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	p.next()
+	var args []bindings.ExpressionType
+	if p.tok == "<" {
+		p.next()
+		for {
+			arg, err := p.union()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.tok != "," {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+	return bindings.Reference(bindings.Identifier{Name: tok}, args...), nil
+}
+
+var typeKeywords = map[string]bindings.LiteralKeyword{
+	"any":       bindings.KeywordAny,
+	"bigint":    bindings.KeywordBigInt,
+	"boolean":   bindings.KeywordBoolean,
+	"never":     bindings.KeywordNever,
+	"number":    bindings.KeywordNumber,
+	"object":    bindings.KeywordObject,
+	"string":    bindings.KeywordString,
+	"undefined": bindings.KeywordUndefined,
+	"unknown":   bindings.KeywordUnknown,
+	"void":      bindings.KeywordVoid,
+}
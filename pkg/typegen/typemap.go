@@ -0,0 +1,296 @@
+package typegen
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+	"github.com/coder/guts/config"
+)
+
+// typeMapping is one parsed entry of Options.TypeMappings.
+type typeMapping struct {
+	// goType is the fully qualified Go type without type parameters, e.g.
+	// "database/sql.Null".
+	goType string
+	// params are the type parameter names of a generic key, in order.
+	params []string
+	expr   bindings.ExpressionType
+}
+
+// parseTypeMappings parses keys of the form "<import path>.<Name>" or
+// "<import path>.<Name>[T, ...]" and their TypeScript values.
+func parseTypeMappings(mappings map[string]string) (map[string]typeMapping, error) {
+	parsed := make(map[string]typeMapping, len(mappings))
+	for key, value := range mappings {
+		goType, params, err := parseMappedGoType(key)
+		if err != nil {
+			return nil, fmt.Errorf("type mapping %q: %w", key, err)
+		}
+		expr, err := parseTypeExpression(value)
+		if err != nil {
+			return nil, fmt.Errorf("type mapping %q: %w", key, err)
+		}
+		if _, ok := parsed[goType]; ok {
+			return nil, fmt.Errorf("type mapping %q: %s is mapped twice", key, goType)
+		}
+		parsed[goType] = typeMapping{goType: goType, params: params, expr: expr}
+	}
+	return parsed, nil
+}
+
+func parseMappedGoType(key string) (string, []string, error) {
+	key = strings.TrimSpace(key)
+	goType, list, generic := strings.Cut(key, "[")
+	var params []string
+	if generic {
+		list, ok := strings.CutSuffix(list, "]")
+		if !ok {
+			return "", nil, fmt.Errorf("missing ]")
+		}
+		for _, param := range strings.Split(list, ",") {
+			param = strings.TrimSpace(param)
+			if !isTypeParamName(param) {
+				return "", nil, fmt.Errorf("invalid type parameter %q", param)
+			}
+			params = append(params, param)
+		}
+	}
+
+	slash := strings.LastIndex(goType, "/")
+	dot := strings.LastIndex(goType, ".")
+	if dot <= slash || dot == len(goType)-1 {
+		return "", nil, fmt.Errorf("expected a fully qualified type such as database/sql.NullString")
+	}
+	return goType, params, nil
+}
+
+func isTypeParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && (i == 0 || !('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// typeMapper installs the standard and user type mappings on a GoParser.
+// guts looks overrides up by the exact type string, so generic mappings are
+// expanded for every instantiation found in the loaded packages.
+type typeMapper struct {
+	golang    *guts.GoParser
+	overrides map[string]guts.TypeOverride
+	generic   map[string]typeMapping
+	// int64 is the type of int64 and uint64 values, nil for number.
+	int64 func() bindings.ExpressionType
+}
+
+// installTypeMappings must run after the packages are included, since
+// generic instantiations are read from their type information. The returned
+// mapper converts further Go types the same way.
+func installTypeMappings(golang *guts.GoParser, mappings map[string]typeMapping, int64Mode Int64Mode) (*typeMapper, error) {
+	m := &typeMapper{
+		golang:    golang,
+		overrides: config.StandardMappings(),
+		generic:   make(map[string]typeMapping),
+		int64:     int64Replacement(int64Mode),
+	}
+	for goType, mapping := range mappings {
+		if len(mapping.params) > 0 {
+			m.generic[goType] = mapping
+			continue
+		}
+		expr := mapping.expr
+		m.overrides[goType] = func() bindings.ExpressionType {
+			return cloneNode(expr).(bindings.ExpressionType)
+		}
+	}
+
+	if len(m.generic) > 0 {
+		importPaths := make([]string, 0, len(golang.Pkgs))
+		for importPath := range golang.Pkgs {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		for _, importPath := range importPaths {
+			info := golang.Pkgs[importPath].TypesInfo
+			if info == nil {
+				continue
+			}
+			for _, inst := range info.Instances {
+				named, ok := inst.Type.(*types.Named)
+				if !ok {
+					continue
+				}
+				if _, err := m.instance(named); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	golang.IncludeCustomDeclaration(m.overrides)
+	return m, nil
+}
+
+// instance registers and returns the override for an instantiation of a
+// generic mapped type. It returns nil when named is not mapped.
+func (m *typeMapper) instance(named *types.Named) (bindings.ExpressionType, error) {
+	origin := named.Origin().Obj()
+	if origin.Pkg() == nil {
+		return nil, nil
+	}
+	mapping, ok := m.generic[origin.Pkg().Path()+"."+origin.Name()]
+	if !ok {
+		return nil, nil
+	}
+
+	args := named.TypeArgs()
+	if args.Len() != len(mapping.params) {
+		return nil, fmt.Errorf("type mapping %s: %d type parameters, %s has %d",
+			mapping.goType, len(mapping.params), named, args.Len())
+	}
+	bound := make(map[string]bindings.ExpressionType, args.Len())
+	for i, param := range mapping.params {
+		arg, err := m.expression(args.At(i))
+		if err != nil {
+			return nil, err
+		}
+		bound[param] = arg
+	}
+
+	expr := bindTypeParams(cloneNode(mapping.expr).(bindings.ExpressionType), bound)
+
+	m.overrides[named.String()] = func() bindings.ExpressionType {
+		return cloneNode(expr).(bindings.ExpressionType)
+	}
+	return expr, nil
+}
+
+// expression converts a type argument of a mapped instantiation. It covers
+// what type arguments usually are; anything else becomes unknown.
+func (m *typeMapper) expression(t types.Type) (bindings.ExpressionType, error) {
+	switch t := t.(type) {
+	case *types.Basic:
+		info := t.Info()
+		switch {
+		case info&types.IsString != 0:
+			return keyword(bindings.KeywordString), nil
+		case (t.Kind() == types.Int64 || t.Kind() == types.Uint64) && m.int64 != nil:
+			return m.int64(), nil
+		case info&types.IsNumeric != 0:
+			return keyword(bindings.KeywordNumber), nil
+		case info&types.IsBoolean != 0:
+			return keyword(bindings.KeywordBoolean), nil
+		}
+	case *types.Pointer:
+		elem, err := m.expression(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return bindings.Union(elem, &bindings.Null{}), nil
+	case *types.Slice:
+		if basic, ok := t.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return keyword(bindings.KeywordString), nil
+		}
+		elem, err := m.expression(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return bindings.Array(elem), nil
+	case *types.Map:
+		key, err := m.expression(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := m.expression(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return bindings.Reference(bindings.Identifier{Name: "Record"}, key, value), nil
+	case *types.TypeParam:
+		return bindings.Reference(bindings.Identifier{Name: t.Obj().Name()}), nil
+	case *types.Interface:
+		if t.Empty() {
+			return keyword(bindings.KeywordAny), nil
+		}
+	case *types.Alias:
+		return m.expression(types.Unalias(t))
+	case *types.Named:
+		if override, ok := m.overrides[t.String()]; ok {
+			return override(), nil
+		}
+		if expr, err := m.instance(t); expr != nil || err != nil {
+			return expr, err
+		}
+		obj := t.Obj()
+		if obj.Pkg() != nil {
+			if _, ok := m.golang.Pkgs[obj.Pkg().Path()]; ok {
+				var args []bindings.ExpressionType
+				for i := range t.TypeArgs().Len() {
+					arg, err := m.expression(t.TypeArgs().At(i))
+					if err != nil {
+						return nil, err
+					}
+					args = append(args, arg)
+				}
+				return bindings.Reference(m.golang.Identifier(obj), args...), nil
+			}
+		}
+		return m.expression(t.Underlying())
+	}
+	return keyword(bindings.KeywordUnknown), nil
+}
+
+func keyword(k bindings.LiteralKeyword) bindings.ExpressionType {
+	return &k
+}
+
+// bindTypeParams returns expr with references to the type parameters in
+// bound replaced by the type arguments. expr is a parseTypeExpression result
+// and is rewritten in place.
+func bindTypeParams(expr bindings.ExpressionType, bound map[string]bindings.ExpressionType) bindings.ExpressionType {
+	switch e := expr.(type) {
+	case *bindings.ReferenceType:
+		if arg, ok := bound[e.Name.Name]; ok && len(e.Arguments) == 0 {
+			return cloneNode(arg).(bindings.ExpressionType)
+		}
+		for i, arg := range e.Arguments {
+			e.Arguments[i] = bindTypeParams(arg, bound)
+		}
+	case *bindings.UnionType:
+		for i, t := range e.Types {
+			e.Types[i] = bindTypeParams(t, bound)
+		}
+	case *bindings.ArrayType:
+		e.Node = bindTypeParams(e.Node, bound)
+	case *bindings.OperatorNodeType:
+		e.Type = bindTypeParams(e.Type, bound)
+	}
+	return expr
+}
+
+// mappedDeclarationKeys returns the keys of declarations generated for
+// mapped types of the scanned packages; every use of them is replaced.
+func mappedDeclarationKeys(pkgImportPath string, packages []packageInfo, mappings map[string]typeMapping) map[string]struct{} {
+	scanned := make(map[string]struct{}, len(packages))
+	for _, pkg := range packages {
+		scanned[pkg.importPath] = struct{}{}
+	}
+
+	keys := make(map[string]struct{})
+	for goType := range mappings {
+		dot := strings.LastIndex(goType, ".")
+		importPath, name := goType[:dot], goType[dot+1:]
+		if _, ok := scanned[importPath]; ok {
+			keys[prefixForImportPath(pkgImportPath, importPath)+name] = struct{}{}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,99 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_TypeMappings(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/money/money.go", `package money
+
+type Money struct {
+	Cents    int64
+	Currency string
+}
+`)
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+import (
+	"database/sql"
+	"net/netip"
+
+	"example.com/test/pkg/money"
+)
+
+type Bar struct {
+	Name string
+}
+
+type Order struct {
+	Total   money.Money
+	Prefix  netip.Prefix
+	Note    sql.Null[string]
+	Bar     sql.Null[Bar]
+	Deleted sql.NullTime
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateTypesWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		StripPrefix: true,
+		TypeMappings: map[string]string{
+			"example.com/test/pkg/money.Money": "string",
+			"net/netip.Prefix":                 "string",
+			"database/sql.Null[T]":             "T | null",
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+
+	for _, want := range []string{
+		"readonly Total: string;",
+		"readonly Prefix: string;",
+		"readonly Note: string | null;",
+		"readonly Bar: Bar | null;",
+		"readonly Deleted: string | null;",
+		"export interface Bar ",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "interface Money") {
+		t.Fatalf("did not expect mapped Money to be declared:\n%s", output)
+	}
+}
+
+func TestParseTypeMappings(t *testing.T) {
+	mappings, err := parseTypeMappings(map[string]string{
+		"database/sql.Null[T]":     "T | null",
+		"example.com/x.Pair[K, V]": "[K, V]",
+	})
+	if err == nil {
+		t.Fatalf("expected tuple value to be rejected, got %v", mappings)
+	}
+
+	mappings, err = parseTypeMappings(map[string]string{
+		"database/sql.Null[T]":     "T | null",
+		"example.com/x.Pair[K, V]": "Record<K, V>",
+	})
+	if err != nil {
+		t.Fatalf("parseTypeMappings: %v", err)
+	}
+	if got := mappings["example.com/x.Pair"].params; len(got) != 2 || got[0] != "K" || got[1] != "V" {
+		t.Fatalf("unexpected params %v", got)
+	}
+
+	for _, key := range []string{"Decimal", "example.com/x.", "example.com/x.Null[T", "example.com/x.Null[1T]"} {
+		if _, err := parseTypeMappings(map[string]string{key: "string"}); err == nil {
+			t.Fatalf("expected error for key %q", key)
+		}
+	}
+}
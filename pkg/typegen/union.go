@@ -0,0 +1,172 @@
+package typegen
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/coder/guts"
+	"github.com/coder/guts/bindings"
+)
+
+// Directives for discriminated unions. "typegen:union <field>" in the doc
+// comment of an interface emits it as a union discriminated by field, and
+// "typegen:variant <value>" on an implementing struct sets its value of that
+// field.
+const (
+	directiveUnion   = "typegen:union"
+	directiveVariant = "typegen:variant"
+)
+
+// directiveValue returns the argument of the directive name on st.
+func (st scannedType) directiveValue(name string) (string, bool) {
+	for _, directive := range st.directives {
+		if value, ok := strings.CutPrefix(directive, name+" "); ok {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// buildUnions declares the interfaces marked with the union directive or
+// listed in unions (keyed by "<import path>.<Name>") as unions of the
+// structs of the scanned packages implementing them. Each member's
+// discriminator property becomes a string literal: an existing literal
+// type (e.g. from a ts tag) or the variant directive.
+func buildUnions(golang *guts.GoParser, decls []*declaration, scan *sourceScan, packages []packageInfo, pkgImportPath string, unions map[string]string) ([]*declaration, error) {
+	byKey := make(map[string]*declaration, len(decls))
+	for _, decl := range decls {
+		byKey[decl.key] = decl
+	}
+	scanned := make(map[string]scannedType, len(scan.types))
+	for _, st := range scan.types {
+		scanned[st.key] = st
+	}
+
+	for _, st := range scan.interfaceTypes {
+		discriminator, ok := st.directiveValue(directiveUnion)
+		if !ok {
+			discriminator, ok = unions[st.importPath+"."+st.name]
+		}
+		if !ok {
+			continue
+		}
+		if discriminator == "" {
+			return nil, fmt.Errorf("union %s (%s): no discriminator field", st.name, scan.positions[st.key])
+		}
+		pkg, ok := golang.Pkgs[st.importPath]
+		if !ok || pkg.Types == nil {
+			continue
+		}
+		obj := pkg.Types.Scope().Lookup(st.name)
+		if obj == nil {
+			continue
+		}
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+
+		var members []*declaration
+		for _, implPkg := range packages {
+			loaded, ok := golang.Pkgs[implPkg.importPath]
+			if !ok || loaded.Types == nil {
+				continue
+			}
+			prefix := prefixForImportPath(pkgImportPath, implPkg.importPath)
+			scope := loaded.Types.Scope()
+			for _, name := range scope.Names() {
+				tn, ok := scope.Lookup(name).(*types.TypeName)
+				if !ok || !tn.Exported() || tn.IsAlias() {
+					continue
+				}
+				if _, isStruct := tn.Type().Underlying().(*types.Struct); !isStruct {
+					continue
+				}
+				if !types.Implements(tn.Type(), iface) && !types.Implements(types.NewPointer(tn.Type()), iface) {
+					continue
+				}
+				if decl, ok := byKey[prefix+name]; ok {
+					if node, ok := decl.node.(*bindings.Interface); ok && len(node.Parameters) == 0 {
+						members = append(members, decl)
+					}
+				}
+			}
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("union %s (%s): no struct implements it", st.name, scan.positions[st.key])
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+
+		seen := make(map[string]string, len(members))
+		refs := make([]bindings.ExpressionType, 0, len(members))
+		for _, member := range members {
+			value, err := discriminate(member, scanned[member.key], discriminator)
+			if err != nil {
+				return nil, fmt.Errorf("union %s (%s): %w", st.name, scan.positions[st.key], err)
+			}
+			if other, dup := seen[value]; dup {
+				return nil, fmt.Errorf("union %s (%s): %s and %s both use %s %q", st.name, scan.positions[st.key], other, scanned[member.key].name, discriminator, value)
+			}
+			seen[value] = scanned[member.key].name
+			refs = append(refs, bindings.Reference(member.node.(*bindings.Interface).Name))
+		}
+
+		node := &bindings.Alias{
+			Name:      bindings.Identifier{Name: st.name, Package: pkg.Types, Prefix: strings.TrimSuffix(st.key, st.name)},
+			Modifiers: []bindings.Modifier{bindings.ModifierExport},
+			Type:      bindings.Union(refs...),
+			Source:    bindings.Source{File: st.file},
+		}
+		decls = append(decls, &declaration{
+			key:    st.key,
+			name:   st.key,
+			node:   node,
+			source: st.file,
+			refs:   referencedKeys(node),
+		})
+		delete(scan.interfaces, st.key)
+		st.kind = KindUnion
+		scan.types = append(scan.types, st)
+	}
+
+	sort.Slice(decls, func(i, j int) bool {
+		return decls[i].key < decls[j].key
+	})
+	return decls, nil
+}
+
+// discriminate sets the discriminator property of member to its string
+// literal and returns the value. Members without a literal are an error
+// rather than falling back to their Go name, which a rename would change.
+func discriminate(member *declaration, st scannedType, discriminator string) (string, error) {
+	name := discriminator
+	for _, prop := range st.properties {
+		if prop.goName == discriminator && !prop.hidden {
+			name = prop.name
+		}
+	}
+	iface := member.node.(*bindings.Interface)
+	for _, field := range iface.Fields {
+		if field.Name != name {
+			continue
+		}
+		value, ok := st.directiveValue(directiveVariant)
+		if literal, isLiteral := field.Type.(*bindings.LiteralType); isLiteral && !ok {
+			if s, isString := literal.Value.(string); isString {
+				value, ok = s, true
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("%s has no %s literal: add //%s <value> to it", st.name, discriminator, directiveVariant)
+		}
+		field.Type = &bindings.LiteralType{Value: value}
+		field.QuestionToken = false
+		member.refs = referencedKeys(member.node)
+		return value, nil
+	}
+	return "", fmt.Errorf("%s has no %s field", st.name, discriminator)
+}
@@ -0,0 +1,116 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypes_DiscriminatedUnions(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/event/event.go", `package event
+
+// Event is a polymorphic payload.
+//
+//typegen:union Kind
+type Event interface {
+	isEvent()
+}
+
+type Envelope struct {
+	Payload Event `+"`json:\"payload\"`"+`
+}
+
+//typegen:variant Created
+type Created struct {
+	Kind string `+"`json:\"kind\"`"+`
+	Name string `+"`json:\"name\"`"+`
+}
+
+func (Created) isEvent() {}
+
+// Deleted is sent on deletion.
+//
+//typegen:variant user.deleted
+type Deleted struct {
+	Kind string `+"`json:\"kind\"`"+`
+}
+
+func (*Deleted) isEvent() {}
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct {
+	Type string  `+"`json:\"type\" ts:\"type=\\\"square\\\"\"`"+`
+	Side float64 `+"`json:\"side\"`"+`
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+`)
+
+	enterModule(t, root)
+
+	opts := Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true, IncludeType: "^Envelope$"}
+	output, err := GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions: %v", err)
+	}
+	for _, want := range []string{
+		"export type Event = Created | Deleted;",
+		`readonly kind: "Created";`,
+		`readonly kind: "user.deleted";`,
+		" * Deleted is sent on deletion.\n */",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "typegen:") || strings.Contains(output, "Shape") {
+		t.Fatalf("expected directives and unmarked interfaces to be left out:\n%s", output)
+	}
+
+	opts.IncludeType = ""
+	opts.Unions = map[string]string{"example.com/test/pkg/event.Shape": "Type"}
+	output, err = GenerateTypesWithOptions(opts)
+	if err != nil {
+		t.Fatalf("GenerateTypesWithOptions (option): %v", err)
+	}
+	if !strings.Contains(output, "export type Shape = Square;") || !strings.Contains(output, `readonly type: "square";`) {
+		t.Fatalf("expected Shape union keeping the ts tag literal:\n%s", output)
+	}
+
+	opts.Unions = map[string]string{"example.com/test/pkg/event.Shape": "Kind"}
+	if _, err := GenerateTypesWithOptions(opts); err == nil || !strings.Contains(err.Error(), "Square has no Kind field") {
+		t.Fatalf("expected missing discriminator error, got %v", err)
+	}
+}
+
+func TestGenerateTypes_UnionMemberWithoutVariant(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/event/event.go", `package event
+
+//typegen:union Kind
+type Event interface {
+	isEvent()
+}
+
+type Created struct {
+	Kind string `+"`json:\"kind\"`"+`
+}
+
+func (Created) isEvent() {}
+`)
+
+	enterModule(t, root)
+
+	_, err := GenerateTypesWithOptions(Options{PkgDir: filepath.Join(root, "pkg")})
+	if err == nil || !strings.Contains(err.Error(), "Created has no Kind literal: add //typegen:variant") {
+		t.Fatalf("expected missing variant error, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package typegen
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchOptions tunes Watch. Zero values select the defaults.
+type WatchOptions struct {
+	// Interval is how often package directories are polled (default 500ms).
+	Interval time.Duration
+	// Debounce is how long sources must stay unchanged before regenerating,
+	// so bursts of saves trigger a single rebuild (default 300ms).
+	Debounce time.Duration
+	// Logger receives rebuild reports. When nil, log.Default() is used.
+	Logger *log.Logger
+}
+
+const (
+	defaultWatchInterval = 500 * time.Millisecond
+	defaultWatchDebounce = 300 * time.Millisecond
+)
+
+// Watch generates output once and then regenerates it whenever a .go file in
+// one of the scanned packages changes, until ctx is cancelled. Files are
+// polled, so no OS-specific notification support is needed. The output file
+// is only rewritten when its content changes, and every rebuild logs the
+// declarations that were added, removed or changed. Generation errors are
+// logged and watching continues.
+func Watch(ctx context.Context, opts Options, output OutputOptions, watch WatchOptions) error {
+	return WatchTargets(ctx, opts, []Target{targetFor(opts, output)}, watch)
+}
+
+// WatchTargets is Watch for several targets; each rebuild parses the
+// packages once and regenerates every target.
+func WatchTargets(ctx context.Context, base Options, targets []Target, watch WatchOptions) error {
+	for _, t := range targets {
+		if t.Output.Stdout || t.Output.OutputPath == "-" {
+			return fmt.Errorf("watch mode requires an output file")
+		}
+	}
+	if base.PkgDir == "" {
+		return fmt.Errorf("pkg-dir is required")
+	}
+	if err := checkTargets(base, targets); err != nil {
+		return err
+	}
+	if watch.Interval <= 0 {
+		watch.Interval = defaultWatchInterval
+	}
+	if watch.Debounce <= 0 {
+		watch.Debounce = defaultWatchDebounce
+	}
+	logger := watch.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	rebuild := func() {
+		tree, err := parse(base)
+		if err != nil {
+			logger.Printf("typegen: generate: %v", err)
+			return
+		}
+		for _, t := range targets {
+			if err := regenerate(tree, base, t, logger); err != nil {
+				logger.Printf("typegen: %v", err)
+			}
+		}
+	}
+
+	snapshot, err := snapshotSources(base)
+	if err != nil {
+		return err
+	}
+	rebuild()
+
+	ticker := time.NewTicker(watch.Interval)
+	defer ticker.Stop()
+
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			next, err := snapshotSources(base)
+			if err != nil {
+				logger.Printf("typegen: %v", err)
+				continue
+			}
+			if !next.equal(snapshot) {
+				snapshot = next
+				changedAt = now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= watch.Debounce {
+				changedAt = time.Time{}
+				rebuild()
+			}
+		}
+	}
+}
+
+// regenerate writes the target file when the generated content differs from
+// it and logs which declarations changed.
+func regenerate(tree *parsedTree, base Options, t Target, logger *log.Logger) error {
+	outPath := resolveOutputPath(t.Output)
+	content, err := tree.render(base, t)
+	if err != nil {
+		return fmt.Errorf("generate %s: %w", t.label(), err)
+	}
+
+	existing, _ := os.ReadFile(outPath)
+	if string(existing) == content {
+		logger.Printf("typegen: %s is up to date", outPath)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return fmt.Errorf("ensure output directory: %w", err)
+	}
+	if err := os.WriteFile(outPath, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write file %s: %w", outPath, err)
+	}
+
+	added, removed, changed := summarizeChanges(string(existing), content)
+	logger.Printf("typegen: wrote %s (added: %s; removed: %s; changed: %s)",
+		outPath, listOrNone(added), listOrNone(removed), listOrNone(changed))
+	return nil
+}
+
+// summarizeChanges compares two generated files declaration by declaration.
+// Brand helpers are listed with parentheses, e.g. "UserID()".
+func summarizeChanges(before, after string) (added, removed, changed []string) {
+	oldBlocks := make(map[blockKey][]string)
+	for _, block := range splitBlocks(before) {
+		oldBlocks[block.key()] = block.lines
+	}
+	newBlocks := make(map[blockKey][]string)
+	for _, block := range splitBlocks(after) {
+		newBlocks[block.key()] = block.lines
+	}
+
+	for key, lines := range newBlocks {
+		if strings.HasPrefix(key.name, "#") {
+			continue
+		}
+		old, ok := oldBlocks[key]
+		switch {
+		case !ok:
+			added = append(added, key.label())
+		case strings.Join(old, "\n") != strings.Join(lines, "\n"):
+			changed = append(changed, key.label())
+		}
+	}
+	for key := range oldBlocks {
+		if strings.HasPrefix(key.name, "#") {
+			continue
+		}
+		if _, ok := newBlocks[key]; !ok {
+			removed = append(removed, key.label())
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+type sourceSnapshot map[string]fileStamp
+
+func (s sourceSnapshot) equal(other sourceSnapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for path, stamp := range s {
+		if o, ok := other[path]; !ok || o.size != stamp.size || !o.modTime.Equal(stamp.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotSources stats the Go files of every package found by findPackages.
+// Packages are rediscovered each time so new directories are picked up.
+func snapshotSources(opts Options) (sourceSnapshot, error) {
+	pkgDir, err := resolvePkgDir(opts.PkgDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve pkg dir: %w", err)
+	}
+	pkgImportPath, err := resolvePkgPath(pkgDir, opts.PkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve pkg import path: %w", err)
+	}
+	packages, err := findPackages(pkgDir, pkgImportPath)
+	if err != nil {
+		return nil, fmt.Errorf("find packages: %w", err)
+	}
+
+	snapshot := make(sourceSnapshot)
+	for _, pkg := range packages {
+		entries, err := os.ReadDir(pkg.dir)
+		if err != nil {
+			return nil, fmt.Errorf("read dir %s: %w", pkg.dir, err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			snapshot[filepath.Join(pkg.dir, name)] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return snapshot, nil
+}
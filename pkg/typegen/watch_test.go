@@ -0,0 +1,133 @@
+package typegen
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSummarizeChanges(t *testing.T) {
+	before := `// Code generated by 'guts'. DO NOT EDIT.
+
+// From foo/dto.go
+export interface Bar {
+    readonly id: number;
+}
+
+// From foo/dto.go
+export interface FooReq {
+    readonly name: string;
+}
+`
+	after := `// Code generated by 'guts'. DO NOT EDIT.
+
+// From foo/dto.go
+export interface Baz {
+    readonly id: number;
+}
+
+// From foo/dto.go
+export interface FooReq {
+    readonly name: number;
+}
+`
+
+	added, removed, changed := summarizeChanges(before, after)
+	if !reflect.DeepEqual(added, []string{"Baz"}) {
+		t.Fatalf("unexpected added %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"Bar"}) {
+		t.Fatalf("unexpected removed %v", removed)
+	}
+	if !reflect.DeepEqual(changed, []string{"FooReq"}) {
+		t.Fatalf("unexpected changed %v", changed)
+	}
+}
+
+func TestSummarizeChanges_BrandHelpers(t *testing.T) {
+	before := `// From user/user.go
+export type UserID = string & {
+    readonly __brand: "UserID";
+};
+
+export function UserID(value: string): UserID {
+    return value as UserID;
+}
+`
+	after := `// From user/user.go
+export type UserID = string & {
+    readonly __brand: "example.com/user.UserID";
+};
+
+export function UserID(value: string): UserID {
+    return value as UserID;
+}
+`
+
+	added, removed, changed := summarizeChanges(before, after)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("unexpected added %v, removed %v", added, removed)
+	}
+	if !reflect.DeepEqual(changed, []string{"UserID"}) {
+		t.Fatalf("expected only the type to change, got %v", changed)
+	}
+
+	added, removed, _ = summarizeChanges(before, before[:strings.Index(before, "export function")])
+	if len(added) != 0 || !reflect.DeepEqual(removed, []string{"UserID()"}) {
+		t.Fatalf("expected the helper to be removed, got added %v, removed %v", added, removed)
+	}
+}
+
+func TestWatch_RegeneratesOnChange(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+	writeFile(t, root, "pkg/foo/dto.go", "package foo\n\ntype Foo struct {\n\tName string\n}\n")
+
+	enterModule(t, root)
+
+	outPath := filepath.Join(root, "index.d.ts")
+	var logs bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, Options{PkgDir: filepath.Join(root, "pkg"), StripPrefix: true}, OutputOptions{OutputPath: outPath},
+			WatchOptions{Interval: 10 * time.Millisecond, Debounce: 30 * time.Millisecond, Logger: log.New(&logs, "", 0)})
+	}()
+	stop := sync.OnceValue(func() error {
+		cancel()
+		return <-done
+	})
+	t.Cleanup(func() { stop() })
+
+	waitForOutput(t, outPath, "readonly Name: string;")
+	writeFile(t, root, "pkg/foo/dto.go", "package foo\n\ntype Foo struct {\n\tName  string\n\tCount int\n}\n")
+	waitForOutput(t, outPath, "readonly Count: number;")
+
+	if err := stop(); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if !strings.Contains(logs.String(), "changed: Foo") {
+		t.Fatalf("expected the rebuild to report Foo as changed:\n%s", logs.String())
+	}
+}
+
+// waitForOutput polls path until it contains want.
+func waitForOutput(t *testing.T, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		if content, err := os.ReadFile(path); err == nil && strings.Contains(string(content), want) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	content, _ := os.ReadFile(path)
+	t.Fatalf("timed out waiting for %q in %s:\n%s", want, path, content)
+}
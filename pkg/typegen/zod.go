@@ -0,0 +1,452 @@
+package typegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coder/guts/bindings"
+)
+
+const zodHeader = "// Code generated by typegen. DO NOT EDIT.\n\nimport { z } from \"zod\";\n"
+
+// GenerateZodWithOptions generates Zod schemas for the declarations that
+// GenerateTypesWithOptions would emit. Each declaration Foo becomes a
+// FooSchema constant plus a `type Foo = z.infer<typeof FooSchema>` alias, so
+// the names line up with the .d.ts output.
+func GenerateZodWithOptions(opts Options) (string, error) {
+	gen, err := generate(opts)
+	if err != nil {
+		return "", err
+	}
+	return renderZod(gen)
+}
+
+func renderZod(gen *generation) (string, error) {
+	vm, err := bindings.New()
+	if err != nil {
+		return "", fmt.Errorf("create typescript bindings: %w", err)
+	}
+	w := &zodWriter{
+		gen:      gen,
+		vm:       vm,
+		position: make(map[string]int),
+	}
+
+	order := w.order()
+	for i, decl := range order {
+		w.position[decl.name] = i
+	}
+
+	var b strings.Builder
+	b.WriteString(zodHeader)
+	for i, decl := range order {
+		out, err := w.declaration(i, decl)
+		if err != nil {
+			return "", fmt.Errorf("zod %s: %w", decl.name, err)
+		}
+		if out == "" {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(out)
+	}
+
+	return b.String(), nil
+}
+
+type zodWriter struct {
+	gen *generation
+	// vm prints the TypeScript declarations that z.infer cannot give.
+	vm *bindings.Bindings
+	// position is the emission index of each name, used to spot forward references.
+	position map[string]int
+	// current is the emission index of the declaration being rendered.
+	current int
+	// params holds the type parameter refs of the declaration being rendered.
+	params map[string]string
+	// cyclic is set when the current declaration references itself or a later one.
+	cyclic bool
+}
+
+// order returns declarations with dependencies first, so that schema
+// constants are defined before use. Cycles are broken with z.lazy.
+func (w *zodWriter) order() []*declaration {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var order []*declaration
+
+	var visit func(decl *declaration)
+	visit = func(decl *declaration) {
+		if state[decl.name] != unvisited {
+			return
+		}
+		state[decl.name] = visiting
+		for _, ref := range decl.refs {
+			if dep, ok := w.gen.byName[ref]; ok {
+				visit(dep)
+			}
+		}
+		state[decl.name] = done
+		order = append(order, decl)
+	}
+
+	for _, decl := range w.gen.decls {
+		visit(decl)
+	}
+	return order
+}
+
+func (w *zodWriter) declaration(index int, decl *declaration) (string, error) {
+	// RPC contracts have no schema counterpart.
+	if decl.rpc {
+		return "", nil
+	}
+	name := decl.name
+	w.current = index
+	w.params = nil
+	w.cyclic = false
+
+	var (
+		schema  string
+		params  []*bindings.TypeParameter
+		comment []bindings.SyntheticComment
+		source  bindings.Source
+	)
+	switch node := decl.node.(type) {
+	case *bindings.Interface:
+		params = node.Parameters
+		w.setParams(params)
+		schema = w.object(node.Fields)
+		var catchall string
+		for _, heritage := range node.Heritage {
+			for _, arg := range heritage.Args {
+				// Records come from inlined maps and accept any other key.
+				if record, ok := recordType(arg); ok {
+					catchall = w.expr(record.Arguments[1])
+					continue
+				}
+				schema = fmt.Sprintf("%s.merge(%s)", w.expr(arg), schema)
+			}
+		}
+		if catchall != "" {
+			schema += ".catchall(" + catchall + ")"
+		}
+		comment = node.Comments()
+		source = node.Source
+	case *bindings.Alias:
+		params = node.Parameters
+		w.setParams(params)
+		schema = w.expr(node.Type)
+		comment = node.Comments()
+		source = node.Source
+	case *bindings.Enum:
+		members := make([]bindings.ExpressionType, 0, len(node.Members))
+		for _, member := range node.Members {
+			members = append(members, member.Value)
+		}
+		schema = w.expr(bindings.Union(members...))
+		source = node.Source
+	default:
+		// Constants have no schema counterpart.
+		return "", nil
+	}
+
+	var b strings.Builder
+	if line, ok := source.SourceComment(); ok {
+		b.WriteString("//" + line.Text + "\n")
+	}
+	for _, c := range comment {
+		// Synthetic comments (lint directives) are specific to the .d.ts output.
+		if !c.Leading || c.DoNotFormat {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(c.Text, "\n"), "\n") {
+			b.WriteString("//" + line + "\n")
+		}
+	}
+
+	if len(params) > 0 {
+		// The schema factory takes a schema per type parameter, while the
+		// type takes TypeScript types like the .d.ts one: Page<string>.
+		var generics, args, inferred []string
+		for _, param := range params {
+			generics = append(generics, param.Name.Name+" extends z.ZodTypeAny")
+			args = append(args, param.Name.Name+": "+param.Name.Name)
+			inferred = append(inferred, "z.infer<"+param.Name.Name+">")
+		}
+		typ, err := w.typeDeclaration(decl)
+		if err != nil {
+			return "", err
+		}
+		if w.cyclic {
+			// A recursive factory needs a return type, and TypeScript cannot
+			// check a z.object of generic schemas against it, so the schema
+			// is asserted to be of the type declared from the same struct.
+			result := fmt.Sprintf("z.ZodType<%s<%s>>", name, strings.Join(inferred, ", "))
+			fmt.Fprintf(&b, "export const %sSchema = <%s>(%s): %s => %s as unknown as %s;\n",
+				name, strings.Join(generics, ", "), strings.Join(args, ", "), result, schema, result)
+		} else {
+			fmt.Fprintf(&b, "export const %sSchema = <%s>(%s) => %s;\n",
+				name, strings.Join(generics, ", "), strings.Join(args, ", "), schema)
+		}
+		b.WriteString(typ)
+		return b.String(), nil
+	}
+
+	if w.cyclic {
+		// Recursive schemas cannot infer their own type, so it is declared
+		// as in the .d.ts output and the schema checked against it.
+		typ, err := w.typeDeclaration(decl)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(typ)
+		fmt.Fprintf(&b, "export const %sSchema: z.ZodType<%s> = %s;\n", name, name, schema)
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "export const %sSchema = %s;\n", name, schema)
+	fmt.Fprintf(&b, "export type %s = z.infer<typeof %sSchema>;\n", name, name)
+	return b.String(), nil
+}
+
+// typeDeclaration prints decl as in the .d.ts output, without its comments.
+func (w *zodWriter) typeDeclaration(decl *declaration) (string, error) {
+	obj, err := w.vm.ToTypescriptNode(decl.node)
+	if err != nil {
+		return "", err
+	}
+	text, err := w.vm.SerializeToTypescript(obj)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "export ") {
+			return strings.Join(lines[i:], "\n") + "\n", nil
+		}
+	}
+	return "", fmt.Errorf("no declaration in %q", text)
+}
+
+func (w *zodWriter) setParams(params []*bindings.TypeParameter) {
+	w.params = make(map[string]string, len(params))
+	for _, param := range params {
+		w.params[param.Name.Ref()] = param.Name.Name
+	}
+}
+
+func (w *zodWriter) object(fields []*bindings.PropertySignature) string {
+	if len(fields) == 0 {
+		return "z.object({})"
+	}
+	var b strings.Builder
+	b.WriteString("z.object({\n")
+	for _, field := range fields {
+		value := w.expr(field.Type)
+		if field.QuestionToken {
+			value += ".optional()"
+		}
+		fmt.Fprintf(&b, "    %s: %s,\n", propertyKey(field.Name), value)
+	}
+	b.WriteString("})")
+	return b.String()
+}
+
+func (w *zodWriter) expr(e bindings.ExpressionType) string {
+	switch e := e.(type) {
+	case nil:
+		return "z.unknown()"
+	case *bindings.LiteralKeyword:
+		return zodKeyword(*e)
+	case *bindings.LiteralType:
+		return "z.literal(" + literalValue(e.Value) + ")"
+	case *bindings.Null:
+		return "z.null()"
+	case *bindings.ArrayType:
+		return "z.array(" + w.expr(e.Node) + ")"
+	case *bindings.TupleType:
+		elems := make([]string, 0, e.Length)
+		for i := 0; i < e.Length; i++ {
+			elems = append(elems, w.expr(e.Node))
+		}
+		return "z.tuple([" + strings.Join(elems, ", ") + "])"
+	case *bindings.OperatorNodeType:
+		if e.Keyword == bindings.KeywordKeyOf {
+			return "z.string()"
+		}
+		return w.expr(e.Type)
+	case *bindings.UnionType:
+		return w.union(e)
+	case *bindings.TypeIntersection:
+		if len(e.Types) == 0 {
+			return "z.unknown()"
+		}
+		if base, brand, ok := brandOf(e); ok {
+			return w.expr(base) + ".brand<" + strconv.Quote(brand) + ">()"
+		}
+		out := w.expr(e.Types[0])
+		for _, t := range e.Types[1:] {
+			out = "z.intersection(" + out + ", " + w.expr(t) + ")"
+		}
+		return out
+	case *bindings.TypeLiteralNode:
+		return w.object(e.Members)
+	case *bindings.ReferenceType:
+		return w.reference(e)
+	case *bindings.ExpressionWithTypeArguments:
+		return w.expr(e.Expression)
+	default:
+		return "z.unknown()"
+	}
+}
+
+func (w *zodWriter) union(u *bindings.UnionType) string {
+	var nullable bool
+	var types []bindings.ExpressionType
+	for _, t := range u.Types {
+		if _, ok := t.(*bindings.Null); ok {
+			nullable = true
+			continue
+		}
+		types = append(types, t)
+	}
+
+	var out string
+	switch {
+	case len(types) == 0:
+		return "z.null()"
+	case len(types) == 1:
+		out = w.expr(types[0])
+	default:
+		if values, ok := stringLiterals(types); ok {
+			quoted := make([]string, 0, len(values))
+			for _, v := range values {
+				quoted = append(quoted, strconv.Quote(v))
+			}
+			out = "z.enum([" + strings.Join(quoted, ", ") + "])"
+			break
+		}
+		elems := make([]string, 0, len(types))
+		for _, t := range types {
+			elems = append(elems, w.expr(t))
+		}
+		out = "z.union([" + strings.Join(elems, ", ") + "])"
+	}
+
+	if nullable {
+		out += ".nullable()"
+	}
+	return out
+}
+
+func (w *zodWriter) reference(ref *bindings.ReferenceType) string {
+	if ref.Name.Name == "Record" && ref.Name.Package == nil && len(ref.Arguments) == 2 {
+		return "z.record(" + w.expr(ref.Arguments[0]) + ", " + w.expr(ref.Arguments[1]) + ")"
+	}
+	if param, ok := w.params[ref.Name.Ref()]; ok {
+		return param
+	}
+
+	name := ref.Name.Ref()
+	if _, ok := w.gen.byName[name]; !ok {
+		return "z.unknown()"
+	}
+
+	var out string
+	if len(ref.Arguments) > 0 {
+		args := make([]string, 0, len(ref.Arguments))
+		for _, arg := range ref.Arguments {
+			args = append(args, w.expr(arg))
+		}
+		out = name + "Schema(" + strings.Join(args, ", ") + ")"
+	} else {
+		out = name + "Schema"
+	}
+
+	if w.position[name] >= w.current {
+		w.cyclic = true
+		return "z.lazy(() => " + out + ")"
+	}
+	return out
+}
+
+func zodKeyword(k bindings.LiteralKeyword) string {
+	switch k {
+	case bindings.KeywordString:
+		return "z.string()"
+	case bindings.KeywordNumber:
+		return "z.number()"
+	case bindings.KeywordBoolean:
+		return "z.boolean()"
+	case bindings.KeywordBigInt:
+		return "z.bigint()"
+	case bindings.KeywordAny:
+		return "z.any()"
+	case bindings.KeywordNever:
+		return "z.never()"
+	case bindings.KeywordUndefined:
+		return "z.undefined()"
+	case bindings.KeywordVoid:
+		return "z.void()"
+	case bindings.KeywordObject:
+		return "z.record(z.string(), z.unknown())"
+	default:
+		return "z.unknown()"
+	}
+}
+
+// stringLiterals reports whether every type is a string literal, as produced
+// by config.EnumAsTypes for string enums.
+func stringLiterals(types []bindings.ExpressionType) ([]string, bool) {
+	values := make([]string, 0, len(types))
+	for _, t := range types {
+		lit, ok := t.(*bindings.LiteralType)
+		if !ok {
+			return nil, false
+		}
+		s, ok := lit.Value.(string)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, true
+}
+
+func literalValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// propertyKey quotes object keys that are not valid identifiers.
+func propertyKey(name string) string {
+	if isIdentifier(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, ch := range name {
+		switch {
+		case ch == '_' || ch == '$':
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z':
+		case i > 0 && ch >= '0' && ch <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package typegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateZod_MatchesTypeNames(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+)
+
+type FooReq struct {
+	Name   string            `+"`json:\"name,omitempty\"`"+`
+	Status Status            `+"`json:\"status\"`"+`
+	Bar    *Bar              `+"`json:\"bar\"`"+`
+	Meta   map[string]string `+"`json:\"meta\"`"+`
+}
+
+type Bar struct {
+	Next *Bar `+"`json:\"next\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateZodWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateZodWithOptions: %v", err)
+	}
+
+	for _, want := range []string{
+		`import { z } from "zod";`,
+		`export const StatusSchema = z.enum(["active", "inactive"]);`,
+		`export type Status = z.infer<typeof StatusSchema>;`,
+		`name: z.string().optional(),`,
+		`status: StatusSchema,`,
+		`bar: BarSchema.nullable(),`,
+		`meta: z.record(z.string(), z.string()),`,
+		`next: z.lazy(() => BarSchema).nullable(),`,
+		`export type FooReq = z.infer<typeof FooReqSchema>;`,
+		`export const BarSchema: z.ZodType<Bar> = z.object({`,
+		`export interface Bar {`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Index(output, "const BarSchema") > strings.Index(output, "const FooReqSchema") {
+		t.Fatalf("expected BarSchema to be declared before FooReqSchema:\n%s", output)
+	}
+}
+
+func TestGenerateZod_GenericTypes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "go.mod", "module example.com/test\n\ngo 1.25.0\n")
+
+	writeFile(t, root, "pkg/foo/dto.go", `package foo
+
+type Page[T any] struct {
+	Items []T `+"`json:\"items\"`"+`
+}
+
+type Tree[T any] struct {
+	Value    T          `+"`json:\"value\"`"+`
+	Children []*Tree[T] `+"`json:\"children\"`"+`
+}
+
+type Listing struct {
+	Names Page[string] `+"`json:\"names\"`"+`
+	Tree  Tree[int]    `+"`json:\"tree\"`"+`
+}
+`)
+
+	enterModule(t, root)
+
+	output, err := GenerateZodWithOptions(Options{
+		PkgDir:      filepath.Join(root, "pkg"),
+		StripPrefix: true,
+	})
+	if err != nil {
+		t.Fatalf("GenerateZodWithOptions: %v", err)
+	}
+
+	for _, want := range []string{
+		`export const PageSchema = <T extends z.ZodTypeAny>(T: T) => z.object({`,
+		`export interface Page<`,
+		`export const TreeSchema = <T extends z.ZodTypeAny>(T: T): z.ZodType<Tree<z.infer<T>>> => z.object({`,
+		`}) as unknown as z.ZodType<Tree<z.infer<T>>>;`,
+		`export interface Tree<`,
+		`names: PageSchema(z.string()),`,
+		`export type Listing = z.infer<typeof ListingSchema>;`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "ReturnType<") {
+		t.Fatalf("expected generic types to be declared, not inferred:\n%s", output)
+	}
+}